@@ -0,0 +1,173 @@
+package astronomy
+
+import (
+	"time"
+)
+
+// Umbrales de elevación solar (grados) de cada evento
+const (
+	sunriseAltitude      = -0.833 // refracción + semidiámetro del disco solar
+	civilAltitude        = -6
+	nauticalAltitude     = -12
+	astronomicalAltitude = -18
+	blueHourAltitude     = -4
+	goldenHourAltitude   = 6
+	moonriseAltitude     = 0.133 // refracción + semidiámetro - paralaje lunar
+)
+
+// scanStep paso de muestreo para encontrar cruces de elevación
+const scanStep = 10 * time.Minute
+
+// Interval intervalo de tiempo (por ejemplo, hora dorada)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Day efemérides solares y lunares de un día local
+type Day struct {
+	Date time.Time
+	Lat  float64
+	Lon  float64
+
+	Sunrise   *time.Time
+	Sunset    *time.Time
+	SolarNoon time.Time
+	// NoonElevation elevación del Sol en el mediodía solar
+	NoonElevation float64
+	DayLength     time.Duration
+	// PolarDay el Sol no se pone en todo el día; PolarNight no sale
+	PolarDay   bool
+	PolarNight bool
+
+	CivilDawn        *time.Time
+	CivilDusk        *time.Time
+	NauticalDawn     *time.Time
+	NauticalDusk     *time.Time
+	AstronomicalDawn *time.Time
+	AstronomicalDusk *time.Time
+
+	GoldenHourMorning *Interval
+	GoldenHourEvening *Interval
+	BlueHourMorning   *Interval
+	BlueHourEvening   *Interval
+
+	Moonrise         *time.Time
+	Moonset          *time.Time
+	MoonIllumination float64
+	MoonPhaseValue   float64
+	MoonPhase        MoonPhase
+}
+
+// ComputeDay calcula las efemérides del día de date (en su zona horaria) para lat/lon
+func ComputeDay(date time.Time, lat, lon float64) Day {
+	loc := date.Location()
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 0, 1)
+
+	day := Day{Date: start, Lat: lat, Lon: lon}
+	sun := sunElevation(lat, lon)
+
+	day.SolarNoon, day.NoonElevation = findMaximum(start, end, sun)
+
+	day.Sunrise, day.Sunset = riseAndSet(start, end, sunriseAltitude, sun)
+	day.CivilDawn, day.CivilDusk = riseAndSet(start, end, civilAltitude, sun)
+	day.NauticalDawn, day.NauticalDusk = riseAndSet(start, end, nauticalAltitude, sun)
+	day.AstronomicalDawn, day.AstronomicalDusk = riseAndSet(start, end, astronomicalAltitude, sun)
+
+	switch {
+	case day.Sunrise != nil && day.Sunset != nil && day.Sunset.After(*day.Sunrise):
+		day.DayLength = day.Sunset.Sub(*day.Sunrise)
+	case day.Sunrise != nil && day.Sunset != nil:
+		// El Sol se pone antes de salir (días cercanos a la noche o el día polar)
+		day.DayLength = end.Sub(start) - day.Sunrise.Sub(*day.Sunset)
+	case day.Sunrise == nil && day.Sunset == nil && day.NoonElevation > sunriseAltitude:
+		day.PolarDay = true
+		day.DayLength = end.Sub(start)
+	case day.Sunrise == nil && day.Sunset == nil:
+		day.PolarNight = true
+	case day.Sunrise != nil:
+		day.DayLength = end.Sub(*day.Sunrise)
+	default:
+		day.DayLength = day.Sunset.Sub(start)
+	}
+
+	blueRise, blueSet := riseAndSet(start, end, blueHourAltitude, sun)
+	goldenRise, goldenSet := riseAndSet(start, end, goldenHourAltitude, sun)
+	day.BlueHourMorning = interval(day.CivilDawn, blueRise)
+	day.BlueHourEvening = interval(blueSet, day.CivilDusk)
+	day.GoldenHourMorning = interval(blueRise, goldenRise)
+	day.GoldenHourEvening = interval(goldenSet, blueSet)
+
+	day.Moonrise, day.Moonset = riseAndSet(start, end, moonriseAltitude, moonElevation(lat, lon))
+	day.MoonIllumination, day.MoonPhaseValue = MoonIllumination(start.Add(12 * time.Hour))
+	day.MoonPhase = PhaseName(day.MoonPhaseValue)
+
+	return day
+}
+
+// riseAndSet busca el primer cruce ascendente y el primer descendente del umbral en [start, end)
+func riseAndSet(start, end time.Time, threshold float64, f func(time.Time) float64) (rise, set *time.Time) {
+	prevT := start
+	prev := f(prevT) - threshold
+
+	for t := start.Add(scanStep); !t.After(end); t = t.Add(scanStep) {
+		cur := f(t) - threshold
+		if prev < 0 && cur >= 0 && rise == nil {
+			crossing := bisect(prevT, t, threshold, f)
+			rise = &crossing
+		}
+		if prev >= 0 && cur < 0 && set == nil {
+			crossing := bisect(prevT, t, threshold, f)
+			set = &crossing
+		}
+		if rise != nil && set != nil {
+			break
+		}
+		prevT, prev = t, cur
+	}
+
+	return rise, set
+}
+
+// bisect refina el instante del cruce del umbral entre a y b
+func bisect(a, b time.Time, threshold float64, f func(time.Time) float64) time.Time {
+	fa := f(a) - threshold
+	for b.Sub(a) > time.Second {
+		mid := a.Add(b.Sub(a) / 2)
+		fm := f(mid) - threshold
+		if (fa < 0) == (fm < 0) {
+			a, fa = mid, fm
+		} else {
+			b = mid
+		}
+	}
+	return a.Add(b.Sub(a) / 2).Truncate(time.Second)
+}
+
+// findMaximum busca el instante de máxima elevación en [start, end)
+func findMaximum(start, end time.Time, f func(time.Time) float64) (time.Time, float64) {
+	best, bestValue := start, f(start)
+	for t := start.Add(scanStep); t.Before(end); t = t.Add(scanStep) {
+		if v := f(t); v > bestValue {
+			best, bestValue = t, v
+		}
+	}
+
+	// Refinamiento por minuto alrededor del máximo muestreado
+	from, to := best.Add(-scanStep), best.Add(scanStep)
+	for t := from; !t.After(to); t = t.Add(time.Minute) {
+		if v := f(t); v > bestValue {
+			best, bestValue = t, v
+		}
+	}
+	return best, bestValue
+}
+
+// interval arma un intervalo solo si ambos extremos existen y están en orden
+func interval(start, end *time.Time) *Interval {
+	if start == nil || end == nil || !end.After(*start) {
+		return nil
+	}
+	return &Interval{Start: *start, End: *end}
+}
@@ -0,0 +1,72 @@
+package astronomy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeDay(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skip("sin base de zonas horarias:", err)
+	}
+	tests := []struct {
+		name     string
+		date     time.Time
+		lat, lon float64
+		sunrise  string // hora local publicada, "" si no sale
+		sunset   string
+		polarDay bool
+		polarNgt bool
+	}{
+		{"Madrid solsticio de verano", time.Date(2024, 6, 21, 0, 0, 0, 0, madrid), 40.4168, -3.7038, "06:44", "21:48", false, false},
+		{"Madrid solsticio de invierno", time.Date(2024, 12, 21, 0, 0, 0, 0, madrid), 40.4168, -3.7038, "08:33", "17:53", false, false},
+		{"Quito equinoccio", time.Date(2024, 3, 20, 0, 0, 0, 0, time.FixedZone("ECT", -5*3600)), -0.1807, -78.4678, "06:18", "18:25", false, false},
+		{"Tromsø verano", time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 69.6492, 18.9553, "", "", true, false},
+		{"Tromsø invierno", time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), 69.6492, 18.9553, "", "", false, true},
+	}
+	for _, tt := range tests {
+		day := ComputeDay(tt.date, tt.lat, tt.lon)
+		if day.PolarDay != tt.polarDay || day.PolarNight != tt.polarNgt {
+			t.Errorf("%s: PolarDay=%v PolarNight=%v", tt.name, day.PolarDay, day.PolarNight)
+		}
+		checkClock(t, tt.name+" amanecer", day.Sunrise, tt.date.Location(), tt.sunrise)
+		checkClock(t, tt.name+" atardecer", day.Sunset, tt.date.Location(), tt.sunset)
+	}
+}
+
+func TestComputeDayLength(t *testing.T) {
+	summer := ComputeDay(time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 51.5, 0)
+	winter := ComputeDay(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), 51.5, 0)
+	if summer.DayLength < 16*time.Hour || summer.DayLength > 17*time.Hour {
+		t.Errorf("día de verano en Londres: %s", summer.DayLength)
+	}
+	if winter.DayLength < 7*time.Hour+30*time.Minute || winter.DayLength > 8*time.Hour+15*time.Minute {
+		t.Errorf("día de invierno en Londres: %s", winter.DayLength)
+	}
+	if summer.CivilDawn == nil || summer.Sunrise == nil || !summer.CivilDawn.Before(*summer.Sunrise) {
+		t.Errorf("el alba civil debería ser antes del amanecer: %v %v", summer.CivilDawn, summer.Sunrise)
+	}
+}
+
+// checkClock compara la hora local de got con want ("HH:MM", o "" si no debería haber
+// hora) con 3 minutos de tolerancia
+func checkClock(t *testing.T, name string, got *time.Time, loc *time.Location, want string) {
+	t.Helper()
+	if want == "" {
+		if got != nil {
+			t.Errorf("%s: %s, no se esperaba hora", name, got.In(loc).Format("15:04"))
+		}
+		return
+	}
+	if got == nil {
+		t.Errorf("%s: sin hora, se esperaba %s", name, want)
+		return
+	}
+	local := got.In(loc)
+	clock, _ := time.Parse("15:04", want)
+	expected := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if diff := local.Sub(expected); diff < -3*time.Minute || diff > 3*time.Minute {
+		t.Errorf("%s: %s, se esperaba %s", name, local.Format("15:04"), want)
+	}
+}
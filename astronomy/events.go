@@ -0,0 +1,66 @@
+package astronomy
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Valores de fase de las fases principales
+const (
+	PhaseNewMoon      = 0.0
+	PhaseFirstQuarter = 0.25
+	PhaseFullMoon     = 0.5
+	PhaseLastQuarter  = 0.75
+)
+
+// phaseStep paso de búsqueda de fases (la fase avanza ~0.0085 cada 6 horas)
+const phaseStep = 6 * time.Hour
+
+// FindPhases devuelve los instantes en que la Luna alcanza la fase target entre from y to,
+// como máximo limit resultados. Los rangos largos llevan miles de pasos: la búsqueda se
+// detiene con el error de ctx si se cancela
+func FindPhases(ctx context.Context, from, to time.Time, target float64, limit int) ([]time.Time, error) {
+	offset := func(t time.Time) float64 {
+		_, phase := MoonIllumination(t)
+		return wrapPhase(phase - target)
+	}
+
+	var found []time.Time
+	prevT := from
+	prev := offset(prevT)
+	for t := from.Add(phaseStep); !t.After(to) && len(found) < limit; t = t.Add(phaseStep) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cur := offset(t)
+		// Cruce ascendente por cero; los saltos de ±0.5 son el lado opuesto del ciclo
+		if prev < 0 && cur >= 0 && cur-prev < 0.25 {
+			found = append(found, bisectPhase(prevT, t, offset))
+		}
+		prevT, prev = t, cur
+	}
+	return found, nil
+}
+
+// wrapPhase lleva una diferencia de fase al rango [-0.5, 0.5)
+func wrapPhase(d float64) float64 {
+	d = math.Mod(d+0.5, 1)
+	if d < 0 {
+		d++
+	}
+	return d - 0.5
+}
+
+// bisectPhase refina el cruce por cero de la diferencia de fase
+func bisectPhase(a, b time.Time, offset func(time.Time) float64) time.Time {
+	for b.Sub(a) > time.Minute {
+		mid := a.Add(b.Sub(a) / 2)
+		if offset(mid) < 0 {
+			a = mid
+		} else {
+			b = mid
+		}
+	}
+	return a.Add(b.Sub(a) / 2).Truncate(time.Minute)
+}
@@ -0,0 +1,56 @@
+package astronomy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFindPhases(t *testing.T) {
+	tests := []struct {
+		target float64
+		from   string
+		want   string // instante publicado (UTC)
+	}{
+		{PhaseNewMoon, "2024-01-01T00:00:00Z", "2024-01-11T11:57:00Z"},
+		{PhaseFirstQuarter, "2024-01-01T00:00:00Z", "2024-01-18T03:52:00Z"},
+		{PhaseFullMoon, "2024-01-01T00:00:00Z", "2024-01-25T17:54:00Z"},
+		{PhaseLastQuarter, "2024-01-01T00:00:00Z", "2024-01-04T03:30:00Z"},
+		{PhaseFullMoon, "2024-06-01T00:00:00Z", "2024-06-22T01:08:00Z"},
+	}
+	for _, tt := range tests {
+		from, _ := time.Parse(time.RFC3339, tt.from)
+		want, _ := time.Parse(time.RFC3339, tt.want)
+		found, err := FindPhases(context.Background(), from, from.AddDate(0, 1, 0), tt.target, 1)
+		if err != nil || len(found) != 1 {
+			t.Fatalf("FindPhases(%v, %s) = %v, %v", tt.target, tt.from, found, err)
+		}
+		if diff := found[0].Sub(want); diff < -15*time.Minute || diff > 15*time.Minute {
+			t.Errorf("FindPhases(%v, %s) = %s, se esperaba %s", tt.target, tt.from, found[0], want)
+		}
+	}
+}
+
+func TestFindPhasesLimit(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	found, err := FindPhases(context.Background(), from, from.AddDate(1, 0, 0), PhaseFullMoon, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2024 tuvo 12 lunas llenas
+	if len(found) != 12 {
+		t.Errorf("%d lunas llenas en 2024, se esperaban 12", len(found))
+	}
+	if found, _ := FindPhases(context.Background(), from, from.AddDate(1, 0, 0), PhaseFullMoon, 3); len(found) != 3 {
+		t.Errorf("con limit 3 se encontraron %d", len(found))
+	}
+}
+
+func TestFindPhasesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := FindPhases(ctx, from, from.AddDate(3, 0, 0), PhaseFullMoon, 50); err != context.Canceled {
+		t.Errorf("err = %v, se esperaba context.Canceled", err)
+	}
+}
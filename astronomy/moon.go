@@ -0,0 +1,99 @@
+package astronomy
+
+import (
+	"math"
+	"time"
+)
+
+// sunDistanceKm distancia media Tierra-Sol usada para la iluminación lunar
+const sunDistanceKm = 149598000.0
+
+// moonEcliptic longitud y latitud eclípticas (grados) y distancia (km) de la Luna con los
+// términos principales de la teoría lunar de Meeus (error de unas décimas de grado)
+func moonEcliptic(t time.Time) (lambda, beta, distance float64) {
+	d := julianDay(t) - j2000
+
+	L := 218.316 + 13.176396*d   // longitud media
+	Mm := 134.963 + 13.064993*d  // anomalía media de la Luna
+	Ms := 357.529 + 0.98560028*d // anomalía media del Sol
+	F := 93.272 + 13.229350*d    // argumento de latitud
+	D := 297.850 + 12.190749*d   // elongación media
+
+	lambda = normalizeDegrees(L +
+		6.289*math.Sin(Mm*rad) +
+		1.274*math.Sin((2*D-Mm)*rad) +
+		0.658*math.Sin(2*D*rad) +
+		0.214*math.Sin(2*Mm*rad) -
+		0.186*math.Sin(Ms*rad) -
+		0.114*math.Sin(2*F*rad))
+	beta = 5.128*math.Sin(F*rad) +
+		0.281*math.Sin((Mm+F)*rad) +
+		0.278*math.Sin((Mm-F)*rad) +
+		0.173*math.Sin((2*D-F)*rad)
+	distance = 385001 - 20905*math.Cos(Mm*rad) - 3699*math.Cos((2*D-Mm)*rad) - 2956*math.Cos(2*D*rad)
+	return lambda, beta, distance
+}
+
+// moonEquatorial ascensión recta, declinación (grados) y distancia (km) de la Luna
+func moonEquatorial(t time.Time) (ra, dec, distance float64) {
+	lambdaDeg, betaDeg, distance := moonEcliptic(t)
+	lambda, beta := lambdaDeg*rad, betaDeg*rad
+
+	eps := 23.4397 * rad
+	ra = normalizeDegrees(math.Atan2(math.Sin(lambda)*math.Cos(eps)-math.Tan(beta)*math.Sin(eps), math.Cos(lambda)) * deg)
+	dec = math.Asin(math.Sin(beta)*math.Cos(eps)+math.Cos(beta)*math.Sin(eps)*math.Sin(lambda)) * deg
+	return ra, dec, distance
+}
+
+// MoonPosition devuelve la elevación y el azimut de la Luna en grados
+func MoonPosition(t time.Time, lat, lon float64) (elevation, azimuth float64) {
+	ra, dec, _ := moonEquatorial(t)
+	return horizontal(t, lat, lon, ra, dec)
+}
+
+// moonElevation atajo para la búsqueda de eventos
+func moonElevation(lat, lon float64) func(time.Time) float64 {
+	return func(t time.Time) float64 {
+		elevation, _ := MoonPosition(t, lat, lon)
+		return elevation
+	}
+}
+
+// MoonIllumination devuelve la fracción iluminada (0-1) y la fase de la Luna según la
+// diferencia de longitud eclíptica con el Sol
+// (0 = nueva, 0.25 = cuarto creciente, 0.5 = llena, 0.75 = cuarto menguante)
+func MoonIllumination(t time.Time) (fraction, phase float64) {
+	sunLambda, _ := sunEcliptic(t)
+	moonLambda, moonBeta, moonDist := moonEcliptic(t)
+
+	// Elongación geocéntrica y ángulo de fase (Sol-Luna visto desde la Luna)
+	elongation := math.Acos(math.Cos(moonBeta*rad) * math.Cos((moonLambda-sunLambda)*rad))
+	inc := math.Atan2(sunDistanceKm*math.Sin(elongation), moonDist-sunDistanceKm*math.Cos(elongation))
+
+	fraction = (1 + math.Cos(inc)) / 2
+	phase = normalizeDegrees(moonLambda-sunLambda) / 360
+	return fraction, phase
+}
+
+// MoonPhase fase lunar; los textos legibles salen del catálogo de i18n ("moon." + Key)
+type MoonPhase struct {
+	Key string // identificador estable (new_moon, waxing_crescent, ...)
+}
+
+// moonPhases las ocho fases, en orden desde luna nueva
+var moonPhases = []MoonPhase{
+	{"new_moon"},
+	{"waxing_crescent"},
+	{"first_quarter"},
+	{"waxing_gibbous"},
+	{"full_moon"},
+	{"waning_gibbous"},
+	{"last_quarter"},
+	{"waning_crescent"},
+}
+
+// PhaseName devuelve la fase lunar correspondiente a un valor de fase (0-1)
+func PhaseName(phase float64) MoonPhase {
+	index := int(math.Floor(phase*8+0.5)) % 8
+	return moonPhases[index]
+}
@@ -0,0 +1,92 @@
+package astronomy
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	rad = math.Pi / 180
+	deg = 180 / math.Pi
+
+	// j2000 día juliano de la época J2000.0
+	j2000 = 2451545.0
+)
+
+// julianDay convierte un instante a día juliano
+func julianDay(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+}
+
+// julianCentury siglos julianos desde J2000.0
+func julianCentury(t time.Time) float64 {
+	return (julianDay(t) - j2000) / 36525
+}
+
+// normalizeDegrees lleva un ángulo al rango [0, 360)
+func normalizeDegrees(a float64) float64 {
+	a = math.Mod(a, 360)
+	if a < 0 {
+		a += 360
+	}
+	return a
+}
+
+// siderealTime tiempo sidéreo local en grados para la longitud dada
+func siderealTime(t time.Time, lon float64) float64 {
+	d := julianDay(t) - j2000
+	T := d / 36525
+	gmst := 280.46061837 + 360.98564736629*d + 0.000387933*T*T - T*T*T/38710000
+	return normalizeDegrees(gmst + lon)
+}
+
+// sunEcliptic longitud eclíptica aparente del Sol y oblicuidad de la eclíptica en grados (algoritmo NOAA)
+func sunEcliptic(t time.Time) (lambda, eps float64) {
+	T := julianCentury(t)
+
+	L0 := normalizeDegrees(280.46646 + T*(36000.76983+T*0.0003032))
+	M := 357.52911 + T*(35999.05029-0.0001537*T)
+	C := math.Sin(M*rad)*(1.914602-T*(0.004817+0.000014*T)) +
+		math.Sin(2*M*rad)*(0.019993-0.000101*T) +
+		math.Sin(3*M*rad)*0.000289
+
+	omega := 125.04 - 1934.136*T
+	lambda = normalizeDegrees(L0 + C - 0.00569 - 0.00478*math.Sin(omega*rad))
+
+	eps0 := 23 + (26+(21.448-T*(46.815+T*(0.00059-T*0.001813)))/60)/60
+	eps = eps0 + 0.00256*math.Cos(omega*rad)
+	return lambda, eps
+}
+
+// sunEquatorial ascensión recta y declinación aparentes del Sol en grados
+func sunEquatorial(t time.Time) (ra, dec float64) {
+	lambda, eps := sunEcliptic(t)
+	ra = normalizeDegrees(math.Atan2(math.Cos(eps*rad)*math.Sin(lambda*rad), math.Cos(lambda*rad)) * deg)
+	dec = math.Asin(math.Sin(eps*rad)*math.Sin(lambda*rad)) * deg
+	return ra, dec
+}
+
+// horizontal convierte coordenadas ecuatoriales a elevación y azimut (desde el norte, sentido horario)
+func horizontal(t time.Time, lat, lon, ra, dec float64) (elevation, azimuth float64) {
+	H := (siderealTime(t, lon) - ra) * rad
+	phi := lat * rad
+	delta := dec * rad
+
+	elevation = math.Asin(math.Sin(phi)*math.Sin(delta)+math.Cos(phi)*math.Cos(delta)*math.Cos(H)) * deg
+	azimuth = normalizeDegrees(math.Atan2(math.Sin(H), math.Cos(H)*math.Sin(phi)-math.Tan(delta)*math.Cos(phi))*deg + 180)
+	return elevation, azimuth
+}
+
+// SunPosition devuelve la elevación y el azimut geométricos del Sol en grados
+func SunPosition(t time.Time, lat, lon float64) (elevation, azimuth float64) {
+	ra, dec := sunEquatorial(t)
+	return horizontal(t, lat, lon, ra, dec)
+}
+
+// sunElevation atajo para la búsqueda de eventos
+func sunElevation(lat, lon float64) func(time.Time) float64 {
+	return func(t time.Time) float64 {
+		elevation, _ := SunPosition(t, lat, lon)
+		return elevation
+	}
+}
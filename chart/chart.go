@@ -0,0 +1,264 @@
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// Formatos de imagen disponibles
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// Dimensiones del gráfico en píxeles
+const (
+	width  = 960
+	height = 480
+)
+
+// Formats devuelve los formatos de imagen disponibles
+func Formats() []string {
+	return []string{FormatPNG, FormatSVG}
+}
+
+// Series datos de un gráfico del tiempo: una curva de temperatura (y opcionalmente
+// una segunda, como la mínima diaria), barras de precipitación y flechas de viento.
+// Todas las listas tienen un valor por etiqueta y ya están en las unidades a mostrar
+type Series struct {
+	Title   string
+	Labels  []string
+	Temp    []float64
+	TempLow []float64 // opcional
+	Precip  []float64
+	Wind    []float64
+	WindDeg []float64 // dirección de donde sopla el viento, en grados (NaN si no se conoce)
+
+	// Leyendas con la unidad, ej: "Temperatura (°C)"
+	TempLabel    string
+	TempLowLabel string
+	PrecipLabel  string
+	WindLabel    string
+}
+
+// Render dibuja el gráfico en el formato indicado y devuelve la imagen y su tipo MIME
+func Render(format string, s Series) ([]byte, string, error) {
+	if len(s.Labels) == 0 {
+		return nil, "", fmt.Errorf("gráfico sin datos")
+	}
+
+	switch format {
+	case FormatPNG:
+		c := newRaster(width, height)
+		draw(c, s)
+		data, err := c.encode()
+		return data, "image/png", err
+	case FormatSVG:
+		c := newVector(width, height)
+		draw(c, s)
+		return c.encode(), "image/svg+xml", nil
+	}
+	return nil, "", fmt.Errorf("formato de gráfico desconocido: %s", format)
+}
+
+// Alineación horizontal del texto respecto de x
+const (
+	alignStart = iota
+	alignMiddle
+	alignEnd
+)
+
+// canvas operaciones de dibujo comunes a PNG y SVG; y del texto es su centro vertical
+type canvas interface {
+	Line(x1, y1, x2, y2 float64, c color.RGBA, width float64)
+	Rect(x, y, w, h float64, c color.RGBA)
+	Circle(x, y, r float64, c color.RGBA)
+	Text(x, y float64, s string, c color.RGBA, align int, size float64)
+	TextWidth(s string, size float64) float64
+}
+
+// Colores del gráfico
+var (
+	colorText    = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	colorAxis    = color.RGBA{0x99, 0x99, 0x99, 0xff}
+	colorGrid    = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+	colorTemp    = color.RGBA{0xdc, 0x26, 0x26, 0xff}
+	colorTempLow = color.RGBA{0x1d, 0x4e, 0xd8, 0xff}
+	colorPrecip  = color.RGBA{0x7d, 0xd3, 0xfc, 0xff}
+	colorRain    = color.RGBA{0x02, 0x84, 0xc7, 0xff}
+	colorWind    = color.RGBA{0x05, 0x96, 0x69, 0xff}
+)
+
+// Márgenes y áreas del gráfico
+const (
+	plotLeft   = 64
+	plotRight  = width - 64
+	plotTop    = 48
+	plotBottom = height - 130
+	labelY     = plotBottom + 16
+	arrowY     = plotBottom + 50
+	speedY     = plotBottom + 76
+	legendY    = height - 18
+	fontSize   = 12
+	titleSize  = 16
+)
+
+// draw dibuja el gráfico completo sobre el lienzo
+func draw(c canvas, s Series) {
+	n := len(s.Labels)
+	slot := float64(plotRight-plotLeft) / float64(n)
+	xAt := func(i int) float64 { return plotLeft + slot*(float64(i)+0.5) }
+
+	c.Rect(0, 0, width, height, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	c.Text(width/2, 22, s.Title, colorText, alignMiddle, titleSize)
+
+	// Escala de temperatura (eje izquierdo) con líneas de guía
+	low, high := bounds(s.Temp, s.TempLow)
+	tempTicks := ticks(low, high, 5)
+	low, high = tempTicks[0], tempTicks[len(tempTicks)-1]
+	tempY := func(v float64) float64 {
+		return plotBottom - (v-low)/(high-low)*(plotBottom-plotTop)
+	}
+	for _, t := range tempTicks {
+		y := tempY(t)
+		c.Line(plotLeft, y, plotRight, y, colorGrid, 1)
+		c.Text(plotLeft-6, y, formatTick(t), colorTemp, alignEnd, fontSize)
+	}
+
+	// Precipitación: barras con escala propia (eje derecho)
+	_, precipMax := bounds(s.Precip)
+	precipTicks := ticks(0, math.Max(precipMax, 0.1), 5)
+	precipTop := precipTicks[len(precipTicks)-1]
+	for _, t := range precipTicks {
+		y := plotBottom - t/precipTop*(plotBottom-plotTop)
+		c.Text(plotRight+6, y, formatTick(t), colorRain, alignStart, fontSize)
+	}
+	barWidth := math.Max(slot*0.6, 1)
+	for i, p := range s.Precip {
+		if p <= 0 {
+			continue
+		}
+		h := p / precipTop * (plotBottom - plotTop)
+		c.Rect(xAt(i)-barWidth/2, plotBottom-h, barWidth, h, colorPrecip)
+	}
+
+	// Ejes
+	c.Line(plotLeft, plotTop, plotLeft, plotBottom, colorAxis, 1)
+	c.Line(plotRight, plotTop, plotRight, plotBottom, colorAxis, 1)
+	c.Line(plotLeft, plotBottom, plotRight, plotBottom, colorAxis, 1)
+
+	// Curvas de temperatura
+	markers := n <= 31
+	for _, curve := range []struct {
+		values []float64
+		color  color.RGBA
+	}{{s.TempLow, colorTempLow}, {s.Temp, colorTemp}} {
+		for i, v := range curve.values {
+			if i > 0 {
+				c.Line(xAt(i-1), tempY(curve.values[i-1]), xAt(i), tempY(v), curve.color, 2)
+			}
+			if markers {
+				c.Circle(xAt(i), tempY(v), 3, curve.color)
+			}
+		}
+	}
+
+	// Etiquetas del eje X, espaciadas para que no se superpongan
+	widest := 0.0
+	for _, label := range s.Labels {
+		widest = math.Max(widest, c.TextWidth(label, fontSize))
+	}
+	for i := 0; i < n; i += every(slot, widest+12) {
+		c.Text(xAt(i), labelY, s.Labels[i], colorText, alignMiddle, fontSize)
+	}
+
+	// Flechas de viento (hacia donde sopla) y velocidad; sin dirección (NaN) solo la velocidad
+	for i := 0; i < len(s.Wind); i += every(slot, 34) {
+		if i < len(s.WindDeg) && !math.IsNaN(s.WindDeg[i]) {
+			arrow(c, xAt(i), arrowY, s.WindDeg[i]+180, 22, colorWind)
+		}
+		c.Text(xAt(i), speedY, formatTick(s.Wind[i]), colorWind, alignMiddle, fontSize)
+	}
+
+	// Leyenda
+	x := float64(plotLeft)
+	for _, item := range []struct {
+		label string
+		color color.RGBA
+	}{{s.TempLabel, colorTemp}, {s.TempLowLabel, colorTempLow}, {s.PrecipLabel, colorPrecip}, {s.WindLabel, colorWind}} {
+		if item.label == "" {
+			continue
+		}
+		c.Rect(x, legendY-6, 12, 12, item.color)
+		c.Text(x+18, legendY, item.label, colorText, alignStart, fontSize)
+		x += 18 + c.TextWidth(item.label, fontSize) + 28
+	}
+}
+
+// arrow dibuja una flecha centrada en (x, y) que apunta al rumbo indicado (0 = norte)
+func arrow(c canvas, x, y, heading, length float64, col color.RGBA) {
+	rad := heading * math.Pi / 180
+	dx, dy := math.Sin(rad), -math.Cos(rad)
+	tipX, tipY := x+dx*length/2, y+dy*length/2
+	c.Line(x-dx*length/2, y-dy*length/2, tipX, tipY, col, 2)
+	for _, side := range []float64{-1, 1} {
+		a := rad + math.Pi + side*0.5
+		c.Line(tipX, tipY, tipX+math.Sin(a)*7, tipY-math.Cos(a)*7, col, 2)
+	}
+}
+
+// every cada cuántos puntos dibujar un elemento de ancho minWidth sin superponerlos
+func every(slot, minWidth float64) int {
+	if slot >= minWidth {
+		return 1
+	}
+	return int(math.Ceil(minWidth / slot))
+}
+
+// bounds mínimo y máximo de todas las listas
+func bounds(lists ...[]float64) (float64, float64) {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, list := range lists {
+		for _, v := range list {
+			low, high = math.Min(low, v), math.Max(high, v)
+		}
+	}
+	if math.IsInf(low, 1) {
+		return 0, 1
+	}
+	return low, high
+}
+
+// ticks divisiones "redondas" (1, 2, 5 × 10^n) que cubren [low, high]
+func ticks(low, high float64, count int) []float64 {
+	if high-low < 1e-9 {
+		low, high = low-1, high+1
+	}
+	raw := (high - low) / float64(count)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := magnitude * 10
+	for _, m := range []float64{1, 2, 5} {
+		if raw <= m*magnitude {
+			step = m * magnitude
+			break
+		}
+	}
+
+	var values []float64
+	for v := math.Floor(low/step) * step; v < high+step-1e-9; v += step {
+		values = append(values, math.Round(v/step)*step)
+	}
+	return values
+}
+
+// formatTick formatea un valor del eje sin decimales innecesarios
+func formatTick(v float64) string {
+	if math.Abs(v-math.Round(v)) < 1e-9 {
+		return fmt.Sprintf("%.0f", v)
+	}
+	if math.Abs(v*10-math.Round(v*10)) < 1e-9 {
+		return fmt.Sprintf("%.1f", v)
+	}
+	return fmt.Sprintf("%.2f", v)
+}
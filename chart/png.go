@@ -0,0 +1,184 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// raster lienzo PNG dibujado píxel a píxel, sin dependencias externas
+type raster struct {
+	img *image.RGBA
+}
+
+// newRaster crea un lienzo PNG del tamaño indicado
+func newRaster(w, h int) *raster {
+	return &raster{img: image.NewRGBA(image.Rect(0, 0, w, h))}
+}
+
+func (r *raster) Line(x1, y1, x2, y2 float64, c color.RGBA, width float64) {
+	steps := math.Max(math.Abs(x2-x1), math.Abs(y2-y1)) * 2
+	if steps < 1 {
+		steps = 1
+	}
+	for i := 0.0; i <= steps; i++ {
+		t := i / steps
+		r.dot(x1+(x2-x1)*t, y1+(y2-y1)*t, width/2, c)
+	}
+}
+
+func (r *raster) Rect(x, y, w, h float64, c color.RGBA) {
+	for py := int(math.Round(y)); py < int(math.Round(y+h)); py++ {
+		for px := int(math.Round(x)); px < int(math.Round(x+w)); px++ {
+			r.img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+func (r *raster) Circle(x, y, radius float64, c color.RGBA) {
+	r.dot(x, y, radius, c)
+}
+
+// dot pinta un disco; con radio menor a un píxel pinta un solo píxel
+func (r *raster) dot(x, y, radius float64, c color.RGBA) {
+	if radius < 0.75 {
+		r.img.SetRGBA(int(math.Round(x)), int(math.Round(y)), c)
+		return
+	}
+	for py := int(math.Floor(y - radius)); py <= int(math.Ceil(y+radius)); py++ {
+		for px := int(math.Floor(x - radius)); px <= int(math.Ceil(x+radius)); px++ {
+			if math.Hypot(float64(px)-x, float64(py)-y) <= radius {
+				r.img.SetRGBA(px, py, c)
+			}
+		}
+	}
+}
+
+func (r *raster) Text(x, y float64, s string, c color.RGBA, align int, size float64) {
+	scale := fontScale(size)
+	switch align {
+	case alignMiddle:
+		x -= r.TextWidth(s, size) / 2
+	case alignEnd:
+		x -= r.TextWidth(s, size)
+	}
+	top := int(math.Round(y - float64(glyphHeight*scale)/2))
+	left := int(math.Round(x))
+
+	for _, ch := range fontText(s) {
+		glyph := glyphs[ch]
+		for row, line := range glyph {
+			for col, bit := range line {
+				if bit != '#' {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						r.img.SetRGBA(left+col*scale+dx, top+row*scale+dy, c)
+					}
+				}
+			}
+		}
+		left += (glyphWidth + 1) * scale
+	}
+}
+
+func (r *raster) TextWidth(s string, size float64) float64 {
+	n := len([]rune(fontText(s)))
+	if n == 0 {
+		return 0
+	}
+	scale := fontScale(size)
+	return float64(n*(glyphWidth+1)*scale - scale)
+}
+
+// encode comprime la imagen en PNG
+func (r *raster) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, r.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fontScale factor de escala de la fuente de mapa de bits para un tamaño en píxeles
+func fontScale(size float64) int {
+	return int(math.Max(1, math.Round(size/6)))
+}
+
+// fontText adapta el texto a los caracteres de la fuente: mayúsculas sin acentos
+func fontText(s string) string {
+	s = strings.ToUpper(accents.Replace(s))
+	return strings.Map(func(ch rune) rune {
+		if _, ok := glyphs[ch]; ok {
+			return ch
+		}
+		return '?'
+	}, s)
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n", "ç", "c", "à", "a", "è", "e", "ò", "o",
+	"Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ú", "U", "Ü", "U", "Ñ", "N", "Ç", "C",
+)
+
+// Tamaño de los caracteres de la fuente
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+)
+
+// glyphs fuente de mapa de bits de 5x7 ("#" = píxel encendido)
+var glyphs = map[rune][glyphHeight]string{
+	'A': {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'B': {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	'C': {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'D': {"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."},
+	'E': {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'F': {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	'G': {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"},
+	'H': {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'I': {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'J': {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+	'K': {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'L': {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'M': {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+	'N': {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+	'O': {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'P': {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'Q': {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+	'R': {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	'S': {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+	'T': {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'U': {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'V': {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'W': {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+	'X': {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+	'Y': {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	'Z': {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+	'0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3': {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	' ': {".....", ".....", ".....", ".....", ".....", ".....", "....."},
+	'.': {".....", ".....", ".....", ".....", ".....", ".##..", ".##.."},
+	',': {".....", ".....", ".....", ".....", ".##..", "..#..", ".#..."},
+	':': {".....", ".##..", ".##..", ".....", ".##..", ".##..", "....."},
+	'-': {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+	'+': {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+	'/': {".....", "....#", "...#.", "..#..", ".#...", "#....", "....."},
+	'°': {".##..", "#..#.", ".##..", ".....", ".....", ".....", "....."},
+	'%': {"##...", "##..#", "...#.", "..#..", ".#...", "#..##", "...##"},
+	'(': {"...#.", "..#..", ".#...", ".#...", ".#...", "..#..", "...#."},
+	')': {".#...", "..#..", "...#.", "...#.", "...#.", "..#..", ".#..."},
+	'?': {".###.", "#...#", "....#", "...#.", "..#..", ".....", "..#.."},
+}
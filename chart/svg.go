@@ -0,0 +1,61 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image/color"
+)
+
+// vector lienzo SVG
+type vector struct {
+	buf bytes.Buffer
+}
+
+// newVector crea un lienzo SVG del tamaño indicado
+func newVector(w, h int) *vector {
+	v := &vector{}
+	fmt.Fprintf(&v.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n", w, h, w, h)
+	return v
+}
+
+func (v *vector) Line(x1, y1, x2, y2 float64, c color.RGBA, width float64) {
+	fmt.Fprintf(&v.buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%.0f" stroke-linecap="round"/>`+"\n",
+		x1, y1, x2, y2, hex(c), width)
+}
+
+func (v *vector) Rect(x, y, w, h float64, c color.RGBA) {
+	fmt.Fprintf(&v.buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", x, y, w, h, hex(c))
+}
+
+func (v *vector) Circle(x, y, r float64, c color.RGBA) {
+	fmt.Fprintf(&v.buf, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"/>`+"\n", x, y, r, hex(c))
+}
+
+func (v *vector) Text(x, y float64, s string, c color.RGBA, align int, size float64) {
+	anchor := "start"
+	switch align {
+	case alignMiddle:
+		anchor = "middle"
+	case alignEnd:
+		anchor = "end"
+	}
+	fmt.Fprintf(&v.buf, `<text x="%.1f" y="%.1f" font-size="%.0f" fill="%s" text-anchor="%s" dominant-baseline="middle">%s</text>`+"\n",
+		x, y, size, hex(c), anchor, html.EscapeString(s))
+}
+
+// TextWidth aproxima el ancho del texto con un ancho medio por carácter
+func (v *vector) TextWidth(s string, size float64) float64 {
+	return float64(len([]rune(s))) * size * 0.6
+}
+
+// encode cierra el documento SVG
+func (v *vector) encode() []byte {
+	v.buf.WriteString("</svg>\n")
+	return v.buf.Bytes()
+}
+
+// hex color en formato #rrggbb
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
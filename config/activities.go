@@ -0,0 +1,132 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+//go:embed activity_profiles.json
+var defaultActivityProfiles []byte
+
+// ActivityProfile umbrales meteorológicos de una actividad para score_activity
+type ActivityProfile struct {
+	Description     string  `json:"description"`
+	MinTempC        float64 `json:"min_temp_c"`
+	MaxTempC        float64 `json:"max_temp_c"`
+	IdealMinTempC   float64 `json:"ideal_min_temp_c"`
+	IdealMaxTempC   float64 `json:"ideal_max_temp_c"`
+	MaxWindKph      float64 `json:"max_wind_kph"`
+	MaxGustKph      float64 `json:"max_gust_kph"`
+	MaxPrecipChance float64 `json:"max_precip_chance"`
+	MaxPrecipMm     float64 `json:"max_precip_mm"`
+	MaxUV           float64 `json:"max_uv"`
+	MinVisibilityKm float64 `json:"min_visibility_km"`
+	DaylightOnly    bool    `json:"daylight_only"`
+}
+
+// LoadActivityProfiles carga los perfiles incluidos y, si path no está vacío,
+// agrega o modifica perfiles con los definidos en ese archivo JSON
+func LoadActivityProfiles(path string) (map[string]ActivityProfile, error) {
+	profiles := map[string]ActivityProfile{}
+	if err := json.Unmarshal(defaultActivityProfiles, &profiles); err != nil {
+		return nil, fmt.Errorf("perfiles de actividad incluidos inválidos: %v", err)
+	}
+
+	if path == "" {
+		return profiles, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer %s: %v", path, err)
+	}
+
+	custom := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("perfiles de actividad inválidos en %s: %v", path, err)
+	}
+
+	for name, raw := range custom {
+		profile, err := mergeActivityProfile(profiles, name, raw)
+		if err == nil {
+			err = profile.validate()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("perfil '%s' en %s: %v", name, path, err)
+		}
+		profiles[name] = profile
+	}
+
+	return profiles, nil
+}
+
+// activityThresholds umbrales que debe definir un perfil propio que no reemplaza a uno
+// incluido
+var activityThresholds = []string{
+	"min_temp_c", "max_temp_c", "ideal_min_temp_c", "ideal_max_temp_c", "max_wind_kph",
+	"max_gust_kph", "max_precip_chance", "max_precip_mm", "max_uv", "min_visibility_km",
+}
+
+// mergeActivityProfile perfil propio sobre el incluido con el mismo nombre: los
+// umbrales que no define conservan el valor incluido. Un perfil nuevo debe definir
+// todos los umbrales
+func mergeActivityProfile(profiles map[string]ActivityProfile, name string, raw json.RawMessage) (ActivityProfile, error) {
+	profile, builtin := profiles[name]
+	if !builtin {
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return profile, err
+		}
+		for _, key := range activityThresholds {
+			if _, ok := fields[key]; !ok {
+				return profile, fmt.Errorf("falta %s", key)
+			}
+		}
+	}
+	if err := json.Unmarshal(raw, &profile); err != nil {
+		return profile, err
+	}
+	return profile, nil
+}
+
+// ActivityNames devuelve los nombres de los perfiles ordenados alfabéticamente
+func (c *Config) ActivityNames() []string {
+	names := make([]string, 0, len(c.ActivityProfiles))
+	for name := range c.ActivityProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate verifica que los umbrales del perfil sean coherentes
+func (p ActivityProfile) validate() error {
+	if p.MinTempC > p.MaxTempC {
+		return fmt.Errorf("min_temp_c mayor que max_temp_c")
+	}
+	if p.IdealMinTempC > p.IdealMaxTempC {
+		return fmt.Errorf("ideal_min_temp_c mayor que ideal_max_temp_c")
+	}
+	if p.IdealMinTempC < p.MinTempC || p.IdealMaxTempC > p.MaxTempC {
+		return fmt.Errorf("el rango ideal de temperatura debe estar dentro de min_temp_c y max_temp_c")
+	}
+	if p.MaxWindKph <= 0 || p.MaxGustKph <= 0 {
+		return fmt.Errorf("max_wind_kph y max_gust_kph deben ser mayores que 0")
+	}
+	if p.MaxPrecipChance < 0 || p.MaxPrecipChance > 100 {
+		return fmt.Errorf("max_precip_chance debe estar entre 0 y 100")
+	}
+	if p.MaxPrecipMm < 0 {
+		return fmt.Errorf("max_precip_mm no puede ser negativo")
+	}
+	if p.MinVisibilityKm < 0 {
+		return fmt.Errorf("min_visibility_km no puede ser negativo")
+	}
+	if p.MaxUV <= 0 {
+		return fmt.Errorf("max_uv debe ser mayor que 0")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadActivityProfiles(t *testing.T) {
+	full := `"min_temp_c": 12, "max_temp_c": 35, "ideal_min_temp_c": 18, "ideal_max_temp_c": 28,
+		"max_wind_kph": 20, "max_gust_kph": 30, "max_precip_chance": 40, "max_precip_mm": 1,
+		"max_uv": 9, "min_visibility_km": 2`
+	tests := []struct {
+		name    string
+		content string
+		err     string // vacío si se espera que cargue
+	}{
+		{"perfil nuevo completo", `{"kayak": {` + full + `}}`, ""},
+		{"perfil nuevo incompleto", `{"kayak": {"max_uv": 9}}`, "falta min_temp_c"},
+		{"incluido con un umbral", `{"running": {"max_uv": 6}}`, ""},
+		{"lluvia negativa", `{"running": {"max_precip_mm": -1}}`, "max_precip_mm"},
+		{"visibilidad negativa", `{"kayak": {` + full + `, "min_visibility_km": -2}}`, "min_visibility_km"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "profiles.json")
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		profiles, err := LoadActivityProfiles(path)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: err = %v, se esperaba un error con %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if _, ok := profiles["cycling"]; !ok {
+			t.Errorf("%s: se perdieron los perfiles incluidos", tt.name)
+		}
+	}
+
+	// Los umbrales que el perfil propio no define conservan el valor incluido
+	builtin, err := LoadActivityProfiles("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(path, []byte(`{"running": {"max_uv": 6}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := LoadActivityProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := builtin["running"]
+	want.MaxUV = 6
+	if merged["running"] != want {
+		t.Errorf("running = %+v, se esperaba %+v", merged["running"], want)
+	}
+}
@@ -3,14 +3,59 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"weather-mcp-server/gazetteer"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/render"
+	"weather-mcp-server/units"
 )
 
 // Config contiene la configuración del servidor
 type Config struct {
-	WeatherAPIKey string
-	BaseURL       string
+	WeatherAPIKey    string
+	BaseURL          string
+	ActivityProfiles map[string]ActivityProfile
+	// Units sistema de unidades por defecto cuando una herramienta no recibe 'units'
+	Units units.System
+	// Lang idioma por defecto de los textos cuando una herramienta no recibe 'lang'
+	Lang string
+	// Format formato de salida por defecto cuando una herramienta no recibe 'format'
+	Format string
+	// Icons set de íconos de condición local; vacío si no se configuró (se usan enlaces)
+	Icons IconSet
+	// ResourceLocations ubicaciones publicadas en resources/list; con cualquier otra
+	// se pueden leer los recursos a partir de las plantillas de URI
+	ResourceLocations []string
+	// FavoriteLocations ubicaciones sugeridas primero al completar argumentos 'location'
+	FavoriteLocations []string
+	// CacheTTL tiempo que se reutilizan las respuestas de WeatherAPI (0 desactiva la caché)
+	CacheTTL time.Duration
+	// RateLimit llamadas por minuto a WeatherAPI como máximo (0 sin límite)
+	RateLimit int
+	// PollInterval cada cuánto se consultan las ubicaciones con suscripciones
+	PollInterval time.Duration
+	// WatchTempDelta variación de temperatura (°C) que dispara una notificación
+	WatchTempDelta float64
+	// MaxMessageSize tamaño máximo en bytes de un mensaje recibido por stdio
+	MaxMessageSize int
+	// Gazetteer lugares de GeoNames para buscar y resolver ubicaciones sin WeatherAPI;
+	// nil si no se configuró
+	Gazetteer *gazetteer.Gazetteer
+	// GazetteerMode cuándo se usa el gazetteer: GazetteerFallback o GazetteerFirst
+	GazetteerMode string
 }
 
+// Modos de uso del gazetteer
+const (
+	// GazetteerFallback solo cuando WeatherAPI no responde
+	GazetteerFallback = "fallback"
+	// GazetteerFirst antes de consultar WeatherAPI, para ahorrar cuota
+	GazetteerFirst = "first"
+)
+
 // LoadConfig carga la configuración desde variables de entorno
 func LoadConfig() (*Config, error) {
 	apiKey := os.Getenv("WEATHER_API_KEY")
@@ -18,12 +63,148 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("WEATHER_API_KEY es requerida. Obtén una gratis en https://www.weatherapi.com/")
 	}
 
+	// Perfiles de actividad: incluidos + opcionalmente los del archivo indicado
+	profiles, err := LoadActivityProfiles(os.Getenv("ACTIVITY_PROFILES_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Sistema de unidades por defecto (metric, imperial, si, uk)
+	system := units.Metric
+	if name := os.Getenv("WEATHER_UNITS"); name != "" {
+		if system, err = units.Parse(name); err != nil {
+			return nil, fmt.Errorf("WEATHER_UNITS inválida: %v", err)
+		}
+	}
+
+	// Catálogos de mensajes adicionales (<idioma>.json) e idioma por defecto
+	if err := i18n.LoadDir(os.Getenv("WEATHER_LOCALES_DIR")); err != nil {
+		return nil, fmt.Errorf("WEATHER_LOCALES_DIR inválido: %v", err)
+	}
+	lang := i18n.DefaultLang
+	if name := os.Getenv("WEATHER_LANG"); name != "" {
+		if lang = i18n.Normalize(name); lang == "" {
+			return nil, fmt.Errorf("WEATHER_LANG inválido: %s (disponibles: %s)", name, strings.Join(i18n.Languages(), ", "))
+		}
+	}
+
+	// Formato de salida por defecto (text, markdown, plain, compact, json)
+	format := render.FormatText
+	if name := os.Getenv("WEATHER_FORMAT"); name != "" {
+		if !render.Valid(name) {
+			return nil, fmt.Errorf("WEATHER_FORMAT inválido: %s (disponibles: %s)", name, strings.Join(render.Formats(), ", "))
+		}
+		format = name
+	}
+
+	// Plantillas de presentación propias; se validan contra los resultados al cargarlas
+	if err := render.LoadDir(os.Getenv("WEATHER_TEMPLATES_DIR")); err != nil {
+		return nil, fmt.Errorf("WEATHER_TEMPLATES_DIR inválido: %v", err)
+	}
+
+	// Set de íconos de condición local opcional
+	icons, err := LoadIconSet(os.Getenv("WEATHER_ICONS_DIR"))
+	if err != nil {
+		return nil, fmt.Errorf("WEATHER_ICONS_DIR inválido: %v", err)
+	}
+
+	// Ubicaciones fijas de los recursos MCP y favoritas para completar argumentos,
+	// separadas por ';' (ej: "Madrid;40.4,-3.7")
+	locations := listEnv("WEATHER_RESOURCE_LOCATIONS")
+	favorites := listEnv("WEATHER_FAVORITE_LOCATIONS")
+
+	// Caché y límite de llamadas a WeatherAPI, compartidos por herramientas y sondeo
+	cacheTTL, err := durationEnv("WEATHER_CACHE_TTL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	rateLimit := 60
+	if value := os.Getenv("WEATHER_RATE_LIMIT"); value != "" {
+		if rateLimit, err = strconv.Atoi(value); err != nil || rateLimit < 0 {
+			return nil, fmt.Errorf("WEATHER_RATE_LIMIT inválido: %s (llamadas por minuto, 0 sin límite)", value)
+		}
+	}
+
+	// Sondeo de las ubicaciones con suscripciones
+	pollInterval, err := durationEnv("WEATHER_POLL_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if pollInterval < 10*time.Second {
+		return nil, fmt.Errorf("WEATHER_POLL_INTERVAL inválido: %s (mínimo 10s)", pollInterval)
+	}
+	tempDelta := 2.0
+	if value := os.Getenv("WEATHER_WATCH_TEMP_DELTA"); value != "" {
+		if tempDelta, err = strconv.ParseFloat(value, 64); err != nil || tempDelta <= 0 {
+			return nil, fmt.Errorf("WEATHER_WATCH_TEMP_DELTA inválido: %s (grados °C mayores a 0)", value)
+		}
+	}
+
+	// Tamaño máximo de los mensajes por stdio; los más grandes se rechazan uno a uno
+	maxMessageSize := 4 << 20
+	if value := os.Getenv("WEATHER_MAX_MESSAGE_SIZE"); value != "" {
+		if maxMessageSize, err = strconv.Atoi(value); err != nil || maxMessageSize < 1024 {
+			return nil, fmt.Errorf("WEATHER_MAX_MESSAGE_SIZE inválido: %s (bytes, mínimo 1024)", value)
+		}
+	}
+
+	// Gazetteer opcional (archivo de ciudades de GeoNames, ej: cities15000.txt)
+	places, err := gazetteer.Load(os.Getenv("WEATHER_GAZETTEER_FILE"))
+	if err != nil {
+		return nil, fmt.Errorf("WEATHER_GAZETTEER_FILE inválido: %v", err)
+	}
+	gazetteerMode := GazetteerFallback
+	if value := os.Getenv("WEATHER_GAZETTEER_MODE"); value != "" {
+		if value != GazetteerFallback && value != GazetteerFirst {
+			return nil, fmt.Errorf("WEATHER_GAZETTEER_MODE inválido: %s (disponibles: %s, %s)", value, GazetteerFallback, GazetteerFirst)
+		}
+		gazetteerMode = value
+	}
+
 	return &Config{
-		WeatherAPIKey: apiKey,
-		BaseURL:       "https://api.weatherapi.com/v1",
+		WeatherAPIKey:     apiKey,
+		BaseURL:           "https://api.weatherapi.com/v1",
+		ActivityProfiles:  profiles,
+		Units:             system,
+		Lang:              lang,
+		Format:            format,
+		Icons:             icons,
+		ResourceLocations: locations,
+		FavoriteLocations: favorites,
+		CacheTTL:          cacheTTL,
+		RateLimit:         rateLimit,
+		PollInterval:      pollInterval,
+		WatchTempDelta:    tempDelta,
+		MaxMessageSize:    maxMessageSize,
+		Gazetteer:         places,
+		GazetteerMode:     gazetteerMode,
 	}, nil
 }
 
+// listEnv lee una lista separada por ';' de una variable de entorno
+func listEnv(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ";") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// durationEnv lee una duración (ej: "90s", "5m") de una variable de entorno
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s inválido: %s (ej: 90s, 5m)", name, value)
+	}
+	return d, nil
+}
+
 // MaskAPIKey enmascara la API key para logging seguro
 func (c *Config) MaskAPIKey() string {
 	if len(c.WeatherAPIKey) < 8 {
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// iconMimeTypes extensiones admitidas en un set de íconos y su tipo MIME
+var iconMimeTypes = map[string]string{
+	".png": "image/png",
+	".svg": "image/svg+xml",
+	".gif": "image/gif",
+	".jpg": "image/jpeg",
+}
+
+// Icon imagen de un ícono de condición
+type Icon struct {
+	MimeType string
+	Data     []byte
+}
+
+// IconSet íconos de condición locales por código de WeatherAPI. Las claves son
+// "day/<código>", "night/<código>" o "<código>" para los que sirven de día y de noche
+type IconSet map[string]Icon
+
+// LoadIconSet carga un set de íconos desde dir. Los archivos se nombran con el
+// código de condición (ej: 1000.png) y pueden ir en subdirectorios day/ y night/
+func LoadIconSet(dir string) (IconSet, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s no es un directorio", dir)
+	}
+
+	icons := IconSet{}
+	for _, variant := range []string{"", "day", "night"} {
+		entries, err := os.ReadDir(filepath.Join(dir, variant))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			mimeType, ok := iconMimeTypes[ext]
+			if entry.IsDir() || !ok {
+				continue
+			}
+			code := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			if _, err := strconv.Atoi(code); err != nil {
+				continue
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, variant, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("no se pudo leer %s: %v", entry.Name(), err)
+			}
+			icons[filepath.ToSlash(filepath.Join(variant, code))] = Icon{MimeType: mimeType, Data: data}
+		}
+	}
+
+	if len(icons) == 0 {
+		return nil, fmt.Errorf("%s no contiene íconos <código>.png|svg|gif|jpg", dir)
+	}
+	return icons, nil
+}
+
+// Lookup busca el ícono de un código de condición, prefiriendo la variante de día o noche
+func (s IconSet) Lookup(code int, isDay bool) (Icon, bool) {
+	variant := "night"
+	if isDay {
+		variant = "day"
+	}
+	if icon, ok := s[fmt.Sprintf("%s/%d", variant, code)]; ok {
+		return icon, true
+	}
+	icon, ok := s[strconv.Itoa(code)]
+	return icon, ok
+}
@@ -0,0 +1,319 @@
+package gazetteer
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Nombres de los archivos opcionales de GeoNames con los nombres de regiones y países;
+// se buscan junto al archivo de ciudades
+const (
+	admin1File  = "admin1CodesASCII.txt"
+	countryFile = "countryInfo.txt"
+)
+
+// Columnas del formato de ciudades de GeoNames (cities15000.txt y similares)
+const (
+	colID = iota
+	colName
+	colASCIIName
+	colAlternateNames
+	colLat
+	colLon
+	colFeatureClass
+	colFeatureCode
+	colCountryCode
+	colCC2
+	colAdmin1
+	colAdmin2
+	colAdmin3
+	colAdmin4
+	colPopulation
+	colElevation
+	colDEM
+	colTimezone
+	colModified
+	cityColumns
+)
+
+// Place lugar del gazetteer
+type Place struct {
+	// GeoNameID identificador de GeoNames (no es un ID de WeatherAPI)
+	GeoNameID   int
+	Name        string
+	ASCIIName   string
+	Lat         float64
+	Lon         float64
+	CountryCode string
+	// Country nombre del país; el código si no se cargó countryInfo.txt
+	Country    string
+	Admin1Code string
+	// Region nombre de la región (admin1); el código si no se cargó admin1CodesASCII.txt
+	Region     string
+	Population int
+	TzID       string
+}
+
+// Gazetteer lugares indexados en memoria por nombre (nombre, nombre ASCII y nombres
+// alternativos en alfabeto latino) para buscar por prefijo y con errores de tipeo
+type Gazetteer struct {
+	places []Place
+	// names claves de nombre normalizadas, ordenadas para buscar por prefijo
+	names []nameKey
+	// fuzzy posiciones en names de las claves de cada grupo de primera (o segunda)
+	// letra y largo, para que la búsqueda con errores de tipeo no recorra todos los
+	// nombres
+	fuzzy map[fuzzyBucket][]int
+	// grid posiciones en places de los lugares de cada celda de la cuadrícula, para que
+	// la geocodificación inversa solo recorra las celdas cercanas
+	grid map[gridCell][]int
+	// countries nombre de cada país por código ISO
+	countries map[string]string
+}
+
+// nameKey nombre normalizado de un lugar
+type nameKey struct {
+	key   string
+	place int
+}
+
+// fuzzyBucket grupo de claves con la misma letra en la posición (0 o 1) y el mismo
+// largo
+type fuzzyBucket struct {
+	position int
+	letter   byte
+	length   int
+}
+
+// gridCell celda de gridDegrees × gridDegrees grados; lon va de 0 a gridColumns-1
+type gridCell struct {
+	lat int
+	lon int
+}
+
+// Load carga un archivo de ciudades en formato GeoNames (.txt o el .zip que lo
+// contiene). Si junto al archivo están admin1CodesASCII.txt y countryInfo.txt se usan
+// para los nombres de regiones y países. Devuelve nil si path está vacío
+func Load(path string) (*Gazetteer, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	reader, closeFn, err := openCities(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+
+	g := &Gazetteer{countries: map[string]string{}}
+	dir := filepath.Dir(path)
+	regions, err := readTable(filepath.Join(dir, admin1File), 2, func(cols []string) (string, string) {
+		return cols[0], cols[1]
+	})
+	if err != nil {
+		return nil, err
+	}
+	g.countries, err = readTable(filepath.Join(dir, countryFile), 5, func(cols []string) (string, string) {
+		return cols[0], cols[4]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20) // los nombres alternativos ocupan mucho
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		place, alternates, err := parseCity(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s línea %d: %v", filepath.Base(path), line, err)
+		}
+		place.Country = g.countryName(place.CountryCode)
+		place.Region = place.Admin1Code
+		if name, ok := regions[place.CountryCode+"."+place.Admin1Code]; ok {
+			place.Region = name
+		}
+		g.add(place, alternates)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("no se pudo leer %s: %v", path, err)
+	}
+	if len(g.places) == 0 {
+		return nil, fmt.Errorf("%s no tiene lugares", path)
+	}
+
+	sort.Slice(g.names, func(i, j int) bool {
+		if g.names[i].key != g.names[j].key {
+			return g.names[i].key < g.names[j].key
+		}
+		return g.names[i].place < g.names[j].place
+	})
+	g.fuzzy = map[fuzzyBucket][]int{}
+	for i, name := range g.names {
+		for position := 0; position < 2 && position < len(name.key); position++ {
+			bucket := fuzzyBucket{position: position, letter: name.key[position], length: len(name.key)}
+			g.fuzzy[bucket] = append(g.fuzzy[bucket], i)
+		}
+	}
+	g.grid = map[gridCell][]int{}
+	for i, place := range g.places {
+		cell := cellOf(place.Lat, place.Lon)
+		g.grid[cell] = append(g.grid[cell], i)
+	}
+	return g, nil
+}
+
+// Len cantidad de lugares cargados
+func (g *Gazetteer) Len() int {
+	if g == nil {
+		return 0
+	}
+	return len(g.places)
+}
+
+// openCities abre el archivo de ciudades; de un .zip usa el primer .txt
+func openCities(path string) (io.Reader, func(), error) {
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("no se pudo abrir %s: %v", path, err)
+		}
+		for _, file := range archive.File {
+			if strings.EqualFold(filepath.Ext(file.Name), ".txt") {
+				r, err := file.Open()
+				if err != nil {
+					archive.Close()
+					return nil, nil, fmt.Errorf("no se pudo abrir %s en %s: %v", file.Name, path, err)
+				}
+				return r, func() { r.Close(); archive.Close() }, nil
+			}
+		}
+		archive.Close()
+		return nil, nil, fmt.Errorf("%s no contiene un archivo .txt", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no se pudo abrir %s: %v", path, err)
+	}
+	return f, func() { f.Close() }, nil
+}
+
+// readTable lee un archivo opcional de GeoNames separado por tabuladores como mapa
+// clave → nombre; si no existe devuelve un mapa vacío
+func readTable(path string, minColumns int, entry func(cols []string) (string, string)) (map[string]string, error) {
+	table := map[string]string{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return table, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir %s: %v", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		cols := strings.Split(text, "\t")
+		if len(cols) < minColumns {
+			continue
+		}
+		key, name := entry(cols)
+		table[key] = name
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("no se pudo leer %s: %v", path, err)
+	}
+	return table, nil
+}
+
+// parseCity interpreta una línea del archivo de ciudades; devuelve también los
+// nombres alternativos
+func parseCity(text string) (Place, []string, error) {
+	cols := strings.Split(text, "\t")
+	if len(cols) < cityColumns-1 { // la fecha de modificación puede faltar
+		return Place{}, nil, fmt.Errorf("se esperaban %d columnas y hay %d", cityColumns, len(cols))
+	}
+
+	id, err := strconv.Atoi(cols[colID])
+	if err != nil {
+		return Place{}, nil, fmt.Errorf("geonameid inválido: %s", cols[colID])
+	}
+	lat, err := strconv.ParseFloat(cols[colLat], 64)
+	if err != nil {
+		return Place{}, nil, fmt.Errorf("latitud inválida: %s", cols[colLat])
+	}
+	lon, err := strconv.ParseFloat(cols[colLon], 64)
+	if err != nil {
+		return Place{}, nil, fmt.Errorf("longitud inválida: %s", cols[colLon])
+	}
+	population, _ := strconv.Atoi(cols[colPopulation])
+
+	var alternates []string
+	if cols[colAlternateNames] != "" {
+		alternates = strings.Split(cols[colAlternateNames], ",")
+	}
+	return Place{
+		GeoNameID:   id,
+		Name:        cols[colName],
+		ASCIIName:   cols[colASCIIName],
+		Lat:         lat,
+		Lon:         lon,
+		CountryCode: cols[colCountryCode],
+		Admin1Code:  cols[colAdmin1],
+		Population:  population,
+		TzID:        cols[colTimezone],
+	}, alternates, nil
+}
+
+// add agrega un lugar con sus claves de nombre. De los nombres alternativos solo se
+// indexan los que quedan en alfabeto latino, que son los que se escriben en las
+// consultas (ej: "Seville" para Sevilla)
+func (g *Gazetteer) add(place Place, alternates []string) {
+	index := len(g.places)
+	g.places = append(g.places, place)
+
+	seen := map[string]bool{}
+	for _, name := range append([]string{place.Name, place.ASCIIName}, alternates...) {
+		key := Fold(name)
+		if key == "" || seen[key] || !isLatinKey(key) {
+			continue
+		}
+		seen[key] = true
+		g.names = append(g.names, nameKey{key: key, place: index})
+	}
+}
+
+// countryName nombre del país por código ISO; el código si no se conoce
+func (g *Gazetteer) countryName(code string) string {
+	if name, ok := g.countries[code]; ok {
+		return name
+	}
+	return code
+}
+
+// isLatinKey si la clave normalizada tiene solo letras ASCII, dígitos y espacios
+func isLatinKey(key string) bool {
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == ' ') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,349 @@
+package gazetteer
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// earthRadiusKm radio medio de la Tierra para las distancias
+const earthRadiusKm = 6371.0
+
+// gridDegrees lado de las celdas de la cuadrícula de coordenadas; gridRows y
+// gridColumns cuántas hay de polo a polo y alrededor del ecuador
+const (
+	gridDegrees = 1.0
+	gridRows    = int(180 / gridDegrees)
+	gridColumns = int(360 / gridDegrees)
+)
+
+// minFuzzyLength largo mínimo de la consulta para buscar con errores de tipeo; con
+// menos letras casi cualquier nombre corto estaría a una edición
+const minFuzzyLength = 4
+
+// MatchKind cómo coincidió un lugar con la consulta
+type MatchKind string
+
+const (
+	MatchExact  MatchKind = "exact"
+	MatchPrefix MatchKind = "prefix"
+	MatchFuzzy  MatchKind = "fuzzy"
+)
+
+// rank orden de los tipos de coincidencia: primero las exactas
+func (k MatchKind) rank() int {
+	switch k {
+	case MatchExact:
+		return 0
+	case MatchPrefix:
+		return 1
+	}
+	return 2
+}
+
+// Filter restringe los lugares a un país (código ISO o nombre) y a una región
+// (código o nombre de admin1); los campos vacíos no filtran
+type Filter struct {
+	Country string
+	Region  string
+}
+
+// Match lugar encontrado por Search
+type Match struct {
+	Place
+	Kind MatchKind
+}
+
+// Search busca lugares por nombre: coincidencias exactas, por prefijo y, si faltan
+// resultados, con errores de tipeo (1 edición hasta 7 letras, 2 desde 8) entre los
+// nombres con la misma primera o segunda letra: un error en la inicial se encuentra
+// si la segunda letra es correcta ("Nadrid"), pero no si falta o sobra ("Adrid"). Las partes después de una coma (ej: "Valencia,
+// Venezuela") deben ser palabras completas de la región o el país, o su código ISO.
+// Ordena por tipo de coincidencia y luego por población
+func (g *Gazetteer) Search(query string, filter Filter, limit int) []Match {
+	if g == nil || limit <= 0 {
+		return nil
+	}
+	parts := strings.Split(query, ",")
+	key := Fold(parts[0])
+	if key == "" {
+		return nil
+	}
+	var qualifiers []string
+	for _, part := range parts[1:] {
+		if q := Fold(part); q != "" {
+			qualifiers = append(qualifiers, q)
+		}
+	}
+
+	found := map[int]MatchKind{}
+	accept := func(index int, kind MatchKind) {
+		if prev, ok := found[index]; ok && prev.rank() <= kind.rank() {
+			return
+		}
+		place := &g.places[index]
+		if !g.placeMatches(filter, place) || !qualified(place, qualifiers) {
+			return
+		}
+		found[index] = kind
+	}
+
+	start := sort.Search(len(g.names), func(i int) bool { return g.names[i].key >= key })
+	for i := start; i < len(g.names) && strings.HasPrefix(g.names[i].key, key); i++ {
+		kind := MatchPrefix
+		if g.names[i].key == key {
+			kind = MatchExact
+		}
+		accept(g.names[i].place, kind)
+	}
+
+	if len(found) < limit && len(key) >= minFuzzyLength {
+		maxEdits := 1
+		if len(key) >= 8 {
+			maxEdits = 2
+		}
+		for length := len(key) - maxEdits; length <= len(key)+maxEdits; length++ {
+			for position := 0; position < 2; position++ {
+				bucket := fuzzyBucket{position: position, letter: key[position], length: length}
+				for _, i := range g.fuzzy[bucket] {
+					name := g.names[i]
+					if _, ok := found[name.place]; ok {
+						continue
+					}
+					if editDistance(key, name.key, maxEdits) <= maxEdits {
+						accept(name.place, MatchFuzzy)
+					}
+				}
+			}
+		}
+	}
+
+	matches := make([]Match, 0, len(found))
+	for index, kind := range found {
+		matches = append(matches, Match{Place: g.places[index], Kind: kind})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Kind.rank() != b.Kind.rank() {
+			return a.Kind.rank() < b.Kind.rank()
+		}
+		if a.Population != b.Population {
+			return a.Population > b.Population
+		}
+		return a.GeoNameID < b.GeoNameID
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Reverse lugar más cercano a las coordenadas (geocodificación inversa) y su
+// distancia en km; false si ningún lugar pasa el filtro. Recorre la cuadrícula en
+// anillos alrededor de la celda de las coordenadas y se detiene cuando los lugares
+// fuera de los anillos ya no pueden estar más cerca que el mejor encontrado
+func (g *Gazetteer) Reverse(lat, lon float64, filter Filter) (Place, float64, bool) {
+	if g == nil {
+		return Place{}, 0, false
+	}
+	best, bestDistance := -1, math.MaxFloat64
+	center := cellOf(lat, lon)
+	visited := map[gridCell]bool{}
+	for ring := 0; len(visited) < len(g.grid) && ring <= gridRows+gridColumns; ring++ {
+		for i := center.lat - ring; i <= center.lat+ring; i++ {
+			if i < -gridRows/2 || i >= gridRows/2 {
+				continue
+			}
+			// En las filas del medio solo las dos columnas del borde: el interior ya se
+			// recorrió en los anillos anteriores
+			step := 2 * ring
+			if i == center.lat-ring || i == center.lat+ring || ring == 0 {
+				step = 1
+			}
+			for j := center.lon - ring; j <= center.lon+ring; j += step {
+				cell := gridCell{lat: i, lon: ((j % gridColumns) + gridColumns) % gridColumns}
+				indexes, ok := g.grid[cell]
+				if !ok || visited[cell] {
+					continue
+				}
+				visited[cell] = true
+				for _, index := range indexes {
+					place := &g.places[index]
+					if !g.placeMatches(filter, place) {
+						continue
+					}
+					if d := Distance(lat, lon, place.Lat, place.Lon); d < bestDistance {
+						best, bestDistance = index, d
+					}
+				}
+			}
+		}
+		if bestDistance <= ringDistance(lat, lon, center, ring) {
+			break
+		}
+	}
+	if best < 0 {
+		return Place{}, 0, false
+	}
+	return g.places[best], bestDistance, true
+}
+
+// cellOf celda de la cuadrícula de unas coordenadas
+func cellOf(lat, lon float64) gridCell {
+	row := int(math.Floor(lat / gridDegrees))
+	if row >= gridRows/2 {
+		row = gridRows/2 - 1 // el polo norte va en la última fila
+	}
+	column := int(math.Floor(lon/gridDegrees)) % gridColumns
+	return gridCell{lat: row, lon: (column + gridColumns) % gridColumns}
+}
+
+// ringDistance distancia mínima en km desde las coordenadas a un punto fuera de los
+// anillos 0..ring alrededor de su celda: hasta el borde norte o sur de los anillos, o
+// hasta el meridiano del borde este u oeste
+func ringDistance(lat, lon float64, center gridCell, ring int) float64 {
+	rad := math.Pi / 180
+	bound := math.MaxFloat64
+	if south := float64(center.lat-ring) * gridDegrees; south > -90 {
+		bound = math.Min(bound, (lat-south)*rad*earthRadiusKm)
+	}
+	if north := float64(center.lat+ring+1) * gridDegrees; north < 90 {
+		bound = math.Min(bound, (north-lat)*rad*earthRadiusKm)
+	}
+	if 2*ring+1 < gridColumns {
+		west := math.Mod(lon, gridDegrees)
+		if west < 0 {
+			west += gridDegrees
+		}
+		gap := math.Min(west, gridDegrees-west) + float64(ring)*gridDegrees
+		// Distancia al meridiano que está gap grados al este u oeste
+		bound = math.Min(bound, earthRadiusKm*math.Asin(math.Cos(lat*rad)*math.Sin(math.Min(gap, 90)*rad)))
+	}
+	return bound
+}
+
+// MatchesFilter si un lugar con esos nombres de país y región (ej: un resultado de
+// WeatherAPI) pasa el filtro. Un código de país se compara con su nombre si se cargó
+// countryInfo.txt; funciona con un Gazetteer nil comparando solo nombres
+func (g *Gazetteer) MatchesFilter(filter Filter, country, region string) bool {
+	if filter.Country != "" {
+		want := Fold(filter.Country)
+		if want != Fold(country) {
+			if g == nil {
+				return false
+			}
+			name, ok := g.countries[strings.ToUpper(strings.TrimSpace(filter.Country))]
+			if !ok || Fold(name) != Fold(country) {
+				return false
+			}
+		}
+	}
+	return filter.Region == "" || Fold(filter.Region) == Fold(region)
+}
+
+// placeMatches si un lugar del gazetteer pasa el filtro; acepta códigos o nombres
+func (g *Gazetteer) placeMatches(filter Filter, place *Place) bool {
+	if filter.Country != "" && !strings.EqualFold(strings.TrimSpace(filter.Country), place.CountryCode) &&
+		Fold(filter.Country) != Fold(place.Country) {
+		return false
+	}
+	if filter.Region != "" && !strings.EqualFold(strings.TrimSpace(filter.Region), place.Admin1Code) &&
+		Fold(filter.Region) != Fold(place.Region) {
+		return false
+	}
+	return true
+}
+
+// qualified si cada calificador es el código de país o aparece como palabras completas
+// en la región o el país: "es" es España pero no "United States"
+func qualified(place *Place, qualifiers []string) bool {
+	for _, q := range qualifiers {
+		if q != strings.ToLower(place.CountryCode) && !containsWords(Fold(place.Region), q) &&
+			!containsWords(Fold(place.Country), q) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsWords si words aparece en text como palabras completas (ambos normalizados)
+func containsWords(text, words string) bool {
+	return strings.Contains(" "+text+" ", " "+words+" ")
+}
+
+// Distance distancia en km entre dos coordenadas (fórmula del haversine)
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// editDistance distancia de edición entre a y b contando una transposición como una
+// sola edición; deja de calcular y devuelve max+1 en cuanto la supera
+func editDistance(a, b string, max int) int {
+	if d := len(a) - len(b); d > max || -d > max {
+		return max + 1
+	}
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && prev2[j-2]+1 < cur[j] {
+				cur[j] = prev2[j-2] + 1
+			}
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// min3 el menor de tres enteros
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// accents letras acentuadas y sus equivalentes sin acento
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a", "ā", "a", "ą", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e", "ē", "e", "ę", "e", "ě", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "ī", "i", "ı", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o", "ō", "o", "ő", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u", "ū", "u", "ů", "u", "ű", "u",
+	"ý", "y", "ÿ", "y", "ñ", "n", "ń", "n", "ň", "n", "ç", "c", "ć", "c", "č", "c",
+	"ś", "s", "š", "s", "ş", "s", "ș", "s", "ź", "z", "ż", "z", "ž", "z", "ř", "r",
+	"ł", "l", "ď", "d", "đ", "d", "ť", "t", "ţ", "t", "ț", "t", "ğ", "g",
+	"ß", "ss", "æ", "ae", "œ", "oe", "þ", "th", "ð", "d",
+	"-", " ", "'", " ", "’", " ", ".", " ",
+)
+
+// Fold normaliza un nombre para compararlo: minúsculas, sin acentos, sin guiones ni
+// apóstrofos y con los espacios colapsados (ej: "Saint-Étienne" → "saint etienne")
+func Fold(name string) string {
+	return strings.Join(strings.Fields(accents.Replace(strings.ToLower(name))), " ")
+}
@@ -0,0 +1,215 @@
+package gazetteer
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testCities lugares en formato GeoNames (19 columnas separadas por tabulaciones)
+var testCities = []string{
+	"3117735\tMadrid\tMadrid\tMadri,Madryt,Мадрид\t40.4165\t-3.70256\tP\tPPLC\tES\t\t29\t\t\t\t3255944\t\t650\tEurope/Madrid\t2024-01-01",
+	"2509954\tValencia\tValencia\tValence,València\t39.46975\t-0.37739\tP\tPPLA\tES\t\t60\t\t\t\t814208\t\t15\tEurope/Madrid\t2024-01-01",
+	"3625549\tValencia\tValencia\t\t10.16202\t-68.00765\tP\tPPLA\tVE\t\t07\t\t\t\t1385202\t\t479\tAmerica/Caracas\t2024-01-01",
+	"1680197\tValencia\tValencia\t\t7.90639\t125.09417\tP\tPPL\tPH\t\t10\t\t\t\t192993\t\t414\tAsia/Manila\t2024-01-01",
+	"3128760\tBarcelona\tBarcelona\tBarcelone\t41.38879\t2.15899\tP\tPPLA\tES\t\t56\t\t\t\t1620343\t\t15\tEurope/Madrid\t2024-01-01",
+	"4671654\tAustin\tAustin\t\t30.26715\t-97.74306\tP\tPPLA\tUS\t\tTX\t\t\t\t961855\t\t149\tAmerica/Chicago\t2024-01-01",
+	"2980291\tSaint-Étienne\tSaint-Etienne\t\t45.43389\t4.39\tP\tPPLA2\tFR\t\t84\t\t\t\t171924\t\t525\tEurope/Paris\t2024-01-01",
+}
+
+var testRegions = []string{
+	"ES.29\tMadrid\tMadrid\t3117732",
+	"ES.60\tValencia\tValencia\t2593113",
+	"ES.56\tCatalonia\tCatalonia\t3336901",
+	"VE.07\tCarabobo\tCarabobo\t3646738",
+	"PH.10\tNorthern Mindanao\tNorthern Mindanao\t7521309",
+	"US.TX\tTexas\tTexas\t4736286",
+	"FR.84\tAuvergne-Rhône-Alpes\tAuvergne-Rhone-Alpes\t11071625",
+}
+
+var testCountries = []string{
+	"ES\tESP\t724\tSP\tSpain",
+	"VE\tVEN\t862\tVE\tVenezuela",
+	"PH\tPHL\t608\tRP\tPhilippines",
+	"US\tUSA\t840\tUS\tUnited States",
+	"FR\tFRA\t250\tFR\tFrance",
+}
+
+// loadTestGazetteer carga los lugares de prueba desde un directorio temporal
+func loadTestGazetteer(t *testing.T) *Gazetteer {
+	t.Helper()
+	dir := t.TempDir()
+	for file, lines := range map[string][]string{
+		"cities15000.txt": testCities,
+		admin1File:        testRegions,
+		countryFile:       append([]string{"#ISO\tISO3\tISO-Numeric\tfips\tCountry"}, testCountries...),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g, err := Load(filepath.Join(dir, "cities15000.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Madrid", "madrid"},
+		{"  São   Paulo ", "sao paulo"},
+		{"Saint-Étienne", "saint etienne"},
+		{"L'Hospitalet", "l hospitalet"},
+		{"Kraków", "krakow"},
+		{"Straße", "strasse"},
+		{"St. Louis", "st louis"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.in); got != tt.want {
+			t.Errorf("Fold(%q) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"madrid", "madrid", 1, 0},
+		{"madird", "madrid", 1, 1}, // transposición
+		{"barcelna", "barcelona", 1, 1},
+		{"valenca", "valencia", 1, 1},
+		{"madrid", "madras", 2, 2},
+		{"madrid", "london", 2, 3}, // supera max: max+1
+		{"bilbao", "bilbaoooo", 1, 2},
+		{"", "abc", 3, 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.max); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, se esperaba %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	g := loadTestGazetteer(t)
+	tests := []struct {
+		query  string
+		filter Filter
+		want   []string // "Nombre/CC" en orden
+		kind   MatchKind
+	}{
+		{"Madrid", Filter{}, []string{"Madrid/ES"}, MatchExact},
+		{"madryt", Filter{}, []string{"Madrid/ES"}, MatchExact},
+		{"saint etienne", Filter{}, []string{"Saint-Étienne/FR"}, MatchExact},
+		{"Valencia", Filter{}, []string{"Valencia/VE", "Valencia/ES", "Valencia/PH"}, MatchExact},
+		{"Barc", Filter{}, []string{"Barcelona/ES"}, MatchPrefix},
+		{"Barcelna", Filter{}, []string{"Barcelona/ES"}, MatchFuzzy},
+		{"Madird", Filter{}, []string{"Madrid/ES"}, MatchFuzzy},
+		// Un error en la inicial se encuentra por la segunda letra, pero no si falta
+		{"Nadrid", Filter{}, []string{"Madrid/ES"}, MatchFuzzy},
+		{"Adrid", Filter{}, nil, ""},
+		{"Valencia, Spain", Filter{}, []string{"Valencia/ES"}, MatchExact},
+		{"Valencia, es", Filter{}, []string{"Valencia/ES"}, MatchExact},
+		{"Valencia, Carabobo", Filter{}, []string{"Valencia/VE"}, MatchExact},
+		{"Valencia, northern mindanao, ph", Filter{}, []string{"Valencia/PH"}, MatchExact},
+		{"Valencia, venez", Filter{}, nil, ""},
+		{"Valencia, us", Filter{}, nil, ""},
+		{"Austin, us", Filter{}, []string{"Austin/US"}, MatchExact},
+		{"Austin, united states", Filter{}, []string{"Austin/US"}, MatchExact},
+		{"Valencia", Filter{Country: "VE"}, []string{"Valencia/VE"}, MatchExact},
+		{"Valencia", Filter{Country: "spain", Region: "60"}, []string{"Valencia/ES"}, MatchExact},
+		{"", Filter{}, nil, ""},
+	}
+	for _, tt := range tests {
+		matches := g.Search(tt.query, tt.filter, 10)
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.Name+"/"+m.CountryCode)
+			if m.Kind != tt.kind {
+				t.Errorf("Search(%q): %s coincidió como %s, se esperaba %s", tt.query, m.Name, m.Kind, tt.kind)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Search(%q, %+v) = %v, se esperaba %v", tt.query, tt.filter, got, tt.want)
+		}
+	}
+
+	if matches := g.Search("Valencia", Filter{}, 2); len(matches) != 2 {
+		t.Errorf("Search con límite 2 devolvió %d resultados", len(matches))
+	}
+}
+
+func TestReverse(t *testing.T) {
+	g := loadTestGazetteer(t)
+	tests := []struct {
+		lat, lon float64
+		filter   Filter
+		want     string
+		maxKm    float64
+	}{
+		{40.42, -3.70, Filter{}, "Madrid", 1},
+		{39.5, -0.4, Filter{}, "Valencia", 10},
+		{41.0, 1.0, Filter{}, "Barcelona", 120},
+		{40.42, -3.70, Filter{Country: "FR"}, "Saint-Étienne", 1200},
+	}
+	for _, tt := range tests {
+		place, distance, ok := g.Reverse(tt.lat, tt.lon, tt.filter)
+		if !ok || place.Name != tt.want || distance > tt.maxKm {
+			t.Errorf("Reverse(%v, %v) = %s a %.1f km (%v), se esperaba %s", tt.lat, tt.lon, place.Name, distance, ok, tt.want)
+		}
+	}
+	if _, _, ok := g.Reverse(0, 0, Filter{Country: "JP"}); ok {
+		t.Errorf("Reverse con un país sin lugares debería devolver false")
+	}
+
+	// La cuadrícula devuelve lo mismo que recorrer todos los lugares, también lejos de
+	// ellos, cerca de los polos y del otro lado del antimeridiano
+	for lat := -90.0; lat <= 90; lat += 7.5 {
+		for lon := -180.0; lon <= 180; lon += 13 {
+			place, distance, _ := g.Reverse(lat, lon, Filter{})
+			want, wantDistance := Place{}, math.MaxFloat64
+			for _, p := range g.places {
+				if d := Distance(lat, lon, p.Lat, p.Lon); d < wantDistance {
+					want, wantDistance = p, d
+				}
+			}
+			if place.GeoNameID != want.GeoNameID || math.Abs(distance-wantDistance) > 1e-9 {
+				t.Errorf("Reverse(%v, %v) = %s a %.1f km, se esperaba %s a %.1f km", lat, lon, place.Name, distance, want.Name, wantDistance)
+			}
+		}
+	}
+
+	var none *Gazetteer
+	if _, _, ok := none.Reverse(0, 0, Filter{}); ok {
+		t.Errorf("Reverse de un Gazetteer nil debería devolver false")
+	}
+}
+
+func TestMatchesFilter(t *testing.T) {
+	g := loadTestGazetteer(t)
+	tests := []struct {
+		filter          Filter
+		country, region string
+		want            bool
+	}{
+		{Filter{}, "Spain", "Valencia", true},
+		{Filter{Country: "ES"}, "Spain", "Valencia", true},
+		{Filter{Country: "españa"}, "Spain", "Valencia", false},
+		{Filter{Country: "spain", Region: "valencia"}, "Spain", "Valencia", true},
+		{Filter{Country: "VE"}, "Spain", "Valencia", false},
+	}
+	for _, tt := range tests {
+		if got := g.MatchesFilter(tt.filter, tt.country, tt.region); got != tt.want {
+			t.Errorf("MatchesFilter(%+v, %q, %q) = %v, se esperaba %v", tt.filter, tt.country, tt.region, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"weather-mcp-server/astronomy"
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/render"
+)
+
+// maxAstronomyRangeDays límite de días por consulta de rango o búsqueda de eventos
+const maxAstronomyRangeDays = 1096
+
+// astronomyPhaseEvents eventos de fase lunar y su valor de fase; el nombre
+// legible es la clave "moon.<evento>" del catálogo
+var astronomyPhaseEvents = map[string]float64{
+	"full_moon":     astronomy.PhaseFullMoon,
+	"new_moon":      astronomy.PhaseNewMoon,
+	"first_quarter": astronomy.PhaseFirstQuarter,
+	"last_quarter":  astronomy.PhaseLastQuarter,
+}
+
+// AstronomyRangeResult resultado estructurado de get_astronomy con rango de fechas
+type AstronomyRangeResult struct {
+	Location AstroPlace         `json:"location"`
+	Days     []AstronomyDayData `json:"days"`
+}
+
+// AstronomyEvent ocurrencia de un evento astronómico
+type AstronomyEvent struct {
+	Date   string `json:"date"`
+	Time   string `json:"time,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// AstronomyEventsResult resultado estructurado de find_astronomy_events
+type AstronomyEventsResult struct {
+	Location  AstroPlace       `json:"location"`
+	Event     string           `json:"event"`
+	StartDate string           `json:"start_date"`
+	EndDate   string           `json:"end_date"`
+	Events    []AstronomyEvent `json:"events"`
+}
+
+// FindAstronomyEvents busca eventos astronómicos en un rango de fechas: próximas fases
+// lunares, días en que el sol sale o se pone antes/después de una hora y el día más largo o corto
+func FindAstronomyEvents(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error) {
+	tr, err := langParam(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := requiredString(tr, params, "location")
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := requiredString(tr, params, "event")
+	if err != nil {
+		return nil, err
+	}
+
+	count := intParam(params, "count", 1)
+	if count < 1 || count > 50 {
+		return nil, tr.Errorf("error.range", "count", 1, 50)
+	}
+
+	// El evento y la hora se validan antes de resolver la ubicación, que puede
+	// consultar a WeatherAPI
+	clock := stringParam(params, "time", "")
+	switch event {
+	case "full_moon", "new_moon", "first_quarter", "last_quarter", "longest_day", "shortest_day":
+	case "sunrise_before", "sunrise_after", "sunset_before", "sunset_after":
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return nil, tr.Errorf("astronomy_events.error_time", event)
+		}
+	default:
+		return nil, tr.Errorf("astronomy_events.error_event", event, "full_moon, new_moon, first_quarter, last_quarter, sunrise_before, sunrise_after, sunset_before, sunset_after, longest_day, shortest_day")
+	}
+
+	place, err := resolveAstroPlace(ctx, cfg, tr, location, time.Now().Format("2006-01-02"), stringParam(params, "timezone", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := astronomyEventRange(tr, params, event, place.loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []AstronomyEvent
+	switch event {
+	case "full_moon", "new_moon", "first_quarter", "last_quarter":
+		phases, err := astronomy.FindPhases(ctx, start, end.AddDate(0, 0, 1), astronomyPhaseEvents[event], count)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range phases {
+			local := t.In(place.loc)
+			events = append(events, AstronomyEvent{
+				Date:   local.Format("2006-01-02"),
+				Time:   local.Format("15:04"),
+				Detail: tr.T("moon." + event),
+			})
+		}
+
+	case "sunrise_before", "sunrise_after", "sunset_before", "sunset_after":
+		if events, err = findSunTimeEvents(ctx, tr, place, start, end, event, clock, count); err != nil {
+			return nil, err
+		}
+
+	case "longest_day", "shortest_day":
+		if events, err = findDayLengthExtreme(ctx, tr, place, start, end, event == "longest_day"); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &AstronomyEventsResult{
+		Location:  *place,
+		Event:     event,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Events:    events,
+	}
+	if result.Events == nil {
+		result.Events = []AstronomyEvent{}
+	}
+
+	return &ToolResult{
+		Structured: result,
+		Document:   astronomyEventsDocument(tr, result),
+	}, nil
+}
+
+// astronomyEventRange calcula el rango de búsqueda. Por defecto busca un año desde hoy;
+// para longest_day/shortest_day usa el año calendario
+func astronomyEventRange(tr i18n.Translator, params map[string]interface{}, event string, loc *time.Location) (time.Time, time.Time, error) {
+	now := time.Now().In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	yearly := event == "longest_day" || event == "shortest_day"
+
+	if value := stringParam(params, "start_date", ""); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return start, start, tr.Errorf("error.date_format", "start_date")
+		}
+		start = t
+	} else if yearly {
+		start = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc)
+	}
+
+	end := start.AddDate(1, 0, -1)
+	if yearly {
+		end = time.Date(start.Year(), 12, 31, 0, 0, 0, 0, loc)
+	}
+	if value := stringParam(params, "end_date", ""); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return start, start, tr.Errorf("error.date_format", "end_date")
+		}
+		end = t
+	}
+
+	if err := validateAstronomyRange(tr, start, end); err != nil {
+		return start, end, err
+	}
+	return start, end, nil
+}
+
+// validateAstronomyRange verifica el orden y el tamaño de un rango de fechas
+func validateAstronomyRange(tr i18n.Translator, start, end time.Time) error {
+	if end.Before(start) {
+		return tr.Errorf("error.range_order")
+	}
+	if end.Sub(start) > maxAstronomyRangeDays*24*time.Hour {
+		return tr.Errorf("error.range_max_days", maxAstronomyRangeDays)
+	}
+	return nil
+}
+
+// astronomyRange arma la tabla día por día de get_astronomy entre start y end
+func astronomyRange(ctx context.Context, tr i18n.Translator, place *AstroPlace, start, end time.Time) (interface{}, error) {
+	if err := validateAstronomyRange(tr, start, end); err != nil {
+		return nil, err
+	}
+
+	days, err := astronomyDays(ctx, place, start, end)
+	if err != nil {
+		return nil, err
+	}
+	result := &AstronomyRangeResult{Location: *place}
+	for _, day := range days {
+		result.Days = append(result.Days, astronomyDayData(tr, day, place.loc))
+	}
+
+	return &ToolResult{
+		Structured: result,
+		Document:   astronomyRangeDocument(tr, result),
+		Template:   "get_astronomy_range",
+	}, nil
+}
+
+// astronomyDays calcula las efemérides de cada día entre start y end (inclusive). Cada
+// día lleva varios miles de cálculos de posición: entre un día y otro se comprueba si
+// la solicitud se canceló
+func astronomyDays(ctx context.Context, place *AstroPlace, start, end time.Time) ([]astronomy.Day, error) {
+	var days []astronomy.Day
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		days = append(days, astronomy.ComputeDay(d, place.Lat, place.Lon))
+	}
+	return days, nil
+}
+
+// findSunTimeEvents busca los días en que el amanecer o el atardecer cumplen la condición horaria
+func findSunTimeEvents(ctx context.Context, tr i18n.Translator, place *AstroPlace, start, end time.Time, event, clock string, count int) ([]AstronomyEvent, error) {
+	var events []AstronomyEvent
+	for d := start; !d.After(end) && len(events) < count; d = d.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		day := astronomyDayData(tr, astronomy.ComputeDay(d, place.Lat, place.Lon), place.loc)
+
+		value, label := day.Sunrise, tr.T("label.sunrise")
+		if strings.HasPrefix(event, "sunset") {
+			value, label = day.Sunset, tr.T("label.sunset")
+		}
+		if value == "" {
+			continue
+		}
+
+		matches := value < clock
+		if strings.HasSuffix(event, "_after") {
+			matches = value > clock
+		}
+		if matches {
+			events = append(events, AstronomyEvent{
+				Date:   day.Date,
+				Time:   value,
+				Detail: tr.T("astronomy_events.sun_time", label, value, day.DayLength),
+			})
+		}
+	}
+	return events, nil
+}
+
+// findDayLengthExtreme busca el día más largo o más corto del rango
+func findDayLengthExtreme(ctx context.Context, tr i18n.Translator, place *AstroPlace, start, end time.Time, longest bool) ([]AstronomyEvent, error) {
+	days, err := astronomyDays(ctx, place, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var best *astronomy.Day
+	for i, day := range days {
+		if best == nil ||
+			(longest && day.DayLength > best.DayLength) ||
+			(!longest && day.DayLength < best.DayLength) {
+			best = &days[i]
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	data := astronomyDayData(tr, *best, place.loc)
+	label := tr.T("astronomy_events.shortest_day")
+	if longest {
+		label = tr.T("astronomy_events.longest_day")
+	}
+	return []AstronomyEvent{{
+		Date:   data.Date,
+		Detail: tr.T("astronomy_events.day_length", label, data.DayLength, orDash(data.Sunrise), orDash(data.Sunset)),
+	}}, nil
+}
+
+// astronomyRangeDocument arma la tabla de un rango de días
+func astronomyRangeDocument(tr i18n.Translator, r *AstronomyRangeResult) *render.Document {
+	table := &render.Table{Columns: []string{
+		tr.T("label.date"), tr.T("label.sunrise"), tr.T("label.sunset"), tr.T("label.day_length"),
+		tr.T("label.moonrise"), tr.T("label.moonset"), tr.T("label.moon_phase"),
+	}}
+	for _, d := range r.Days {
+		table.Rows = append(table.Rows, []string{
+			d.Date, orDash(d.Sunrise), orDash(d.Sunset), d.DayLength,
+			orDash(d.Moonrise), orDash(d.Moonset), tr.T("astronomy.phase", d.MoonPhaseName, d.MoonIllumination),
+		})
+	}
+
+	doc := &render.Document{
+		Icon:  "🌌",
+		Title: tr.T("astronomy_range.title", len(r.Days)),
+		Fields: []render.Field{
+			{Icon: "📍", Label: tr.T("label.location"), Value: r.Location.label()},
+			{Icon: "💡", Label: tr.T("label.timezone"), Value: r.Location.TzID},
+		},
+		Sections: []render.Section{{Table: table}},
+	}
+	if n := len(r.Days); n > 0 {
+		first, last := r.Days[0], r.Days[n-1]
+		doc.Summary = tr.T("astronomy_range.summary", r.Location.label(),
+			first.Date, orDash(first.Sunrise), orDash(first.Sunset), first.DayLength,
+			last.Date, orDash(last.Sunrise), orDash(last.Sunset), last.DayLength)
+	}
+	if r.Location.Source == "local" {
+		doc.Notes = []render.Field{{Icon: "⚠️", Value: tr.T("astronomy.offline")}}
+	}
+	return doc
+}
+
+// astronomyEventsDocument arma la presentación de find_astronomy_events
+func astronomyEventsDocument(tr i18n.Translator, r *AstronomyEventsResult) *render.Document {
+	doc := &render.Document{
+		Icon:  "🔭",
+		Title: tr.T("astronomy_events.title"),
+		Fields: []render.Field{
+			{Icon: "📍", Label: tr.T("label.location"), Value: r.Location.label()},
+			{Icon: "🎯", Label: tr.T("label.event"), Value: r.Event},
+			{Icon: "📅", Label: tr.T("label.range"), Value: r.StartDate + " → " + r.EndDate},
+		},
+		Notes: []render.Field{{Icon: "💡", Label: tr.T("label.timezone"), Value: r.Location.TzID}},
+	}
+
+	if len(r.Events) == 0 {
+		doc.Summary = tr.T("astronomy_events.none")
+		doc.Notes = append([]render.Field{{Icon: "❌", Value: doc.Summary}}, doc.Notes...)
+		return doc
+	}
+
+	var items []string
+	for _, e := range r.Events {
+		when := e.Date
+		if e.Time != "" {
+			when += " " + e.Time
+		}
+		items = append(items, when+" — "+e.Detail)
+	}
+	doc.Sections = []render.Section{{List: items, Ordered: true}}
+	doc.Summary = r.Location.label() + ": " + strings.Join(items, "; ")
+	return doc
+}
+
+// orDash reemplaza valores vacíos por un guion
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+)
+
+func TestFindDayLengthExtreme(t *testing.T) {
+	tr := i18n.For("es")
+	place := &AstroPlace{Name: "Londres", Lat: 51.5, Lon: 0, TzID: "UTC", loc: time.UTC}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		longest bool
+		date    string
+	}{
+		{true, "2024-06-20"},
+		{false, "2024-12-21"},
+	}
+	for _, tt := range tests {
+		events, err := findDayLengthExtreme(context.Background(), tr, place, start, end, tt.longest)
+		if err != nil || len(events) != 1 {
+			t.Fatalf("findDayLengthExtreme(longest=%v) = %v, %v", tt.longest, events, err)
+		}
+		// El día más largo puede caer un día antes o después del solsticio según el redondeo
+		got, _ := time.Parse("2006-01-02", events[0].Date)
+		want, _ := time.Parse("2006-01-02", tt.date)
+		if diff := got.Sub(want); diff < -48*time.Hour || diff > 48*time.Hour {
+			t.Errorf("findDayLengthExtreme(longest=%v) = %s, se esperaba cerca de %s", tt.longest, events[0].Date, tt.date)
+		}
+	}
+}
+
+func TestAstronomyEventsCancelled(t *testing.T) {
+	tr := i18n.For("es")
+	place := &AstroPlace{Name: "Madrid", Lat: 40.4, Lon: -3.7, TzID: "UTC", loc: time.UTC}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(3, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := astronomyDays(ctx, place, start, end); err != context.Canceled {
+		t.Errorf("astronomyDays: err = %v, se esperaba context.Canceled", err)
+	}
+	if _, err := findSunTimeEvents(ctx, tr, place, start, end, "sunrise_before", "05:00", 50); err != context.Canceled {
+		t.Errorf("findSunTimeEvents: err = %v, se esperaba context.Canceled", err)
+	}
+	if _, err := findDayLengthExtreme(ctx, tr, place, start, end, true); err != context.Canceled {
+		t.Errorf("findDayLengthExtreme: err = %v, se esperaba context.Canceled", err)
+	}
+}
+
+func TestFindAstronomyEventsValidation(t *testing.T) {
+	// Sin WeatherAPI: los parámetros inválidos se rechazan antes de resolver la ubicación
+	cfg := &config.Config{BaseURL: "http://127.0.0.1:1", RateLimit: 60}
+	tr := i18n.For("es")
+	tests := []struct {
+		params map[string]interface{}
+		want   error
+	}{
+		{map[string]interface{}{"event": "eclipse"}, tr.Errorf("astronomy_events.error_event", "eclipse", "full_moon, new_moon, first_quarter, last_quarter, sunrise_before, sunrise_after, sunset_before, sunset_after, longest_day, shortest_day")},
+		{map[string]interface{}{"event": "sunrise_before"}, tr.Errorf("astronomy_events.error_time", "sunrise_before")},
+		{map[string]interface{}{"event": "sunset_after", "time": "25:00"}, tr.Errorf("astronomy_events.error_time", "sunset_after")},
+	}
+	for _, tt := range tests {
+		tt.params["location"] = "Villa Inexistente"
+		tt.params["lang"] = "es"
+		_, err := FindAstronomyEvents(context.Background(), cfg, tt.params)
+		if err == nil || err.Error() != tt.want.Error() {
+			t.Errorf("%v: err = %v, se esperaba %v", tt.params["event"], err, tt.want)
+		}
+	}
+}
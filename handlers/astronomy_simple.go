@@ -1,13 +1,16 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
+	"math"
 	"net/url"
 	"time"
 
+	"weather-mcp-server/astronomy"
 	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/render"
 )
 
 // SimpleAstronomyResponse estructura simplificada sin moon_illumination
@@ -32,82 +35,356 @@ type SimpleAstronomyResponse struct {
 	} `json:"astronomy"`
 }
 
+// AstroPlace ubicación usada para los cálculos astronómicos locales
+type AstroPlace struct {
+	LocationIdentity
+	Name      string  `json:"name"`
+	Region    string  `json:"region,omitempty"`
+	Country   string  `json:"country,omitempty"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	TzID      string  `json:"tz_id"`
+	Localtime string  `json:"localtime,omitempty"`
+	// Source indica de dónde salió la ubicación: "weatherapi" o "local" (sin conexión)
+	Source string `json:"source"`
+
+	loc *time.Location
+}
+
+// label nombre legible "Ciudad, Región, País"
+func (p AstroPlace) label() string {
+	return placeLabel(PlaceData{Name: p.Name, Region: p.Region, Country: p.Country})
+}
+
+// AstroIntervalData intervalo en hora local
+type AstroIntervalData struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// AstronomyDayData efemérides de un día en hora local, calculadas por el motor astronómico
+type AstronomyDayData struct {
+	Date              string             `json:"date"`
+	Sunrise           string             `json:"sunrise,omitempty"`
+	Sunset            string             `json:"sunset,omitempty"`
+	SolarNoon         string             `json:"solar_noon"`
+	NoonElevation     float64            `json:"noon_elevation"`
+	DayLength         string             `json:"day_length"`
+	DayLengthMinutes  int                `json:"day_length_minutes"`
+	PolarDay          bool               `json:"polar_day,omitempty"`
+	PolarNight        bool               `json:"polar_night,omitempty"`
+	CivilDawn         string             `json:"civil_dawn,omitempty"`
+	CivilDusk         string             `json:"civil_dusk,omitempty"`
+	NauticalDawn      string             `json:"nautical_dawn,omitempty"`
+	NauticalDusk      string             `json:"nautical_dusk,omitempty"`
+	AstronomicalDawn  string             `json:"astronomical_dawn,omitempty"`
+	AstronomicalDusk  string             `json:"astronomical_dusk,omitempty"`
+	GoldenHourMorning *AstroIntervalData `json:"golden_hour_morning,omitempty"`
+	GoldenHourEvening *AstroIntervalData `json:"golden_hour_evening,omitempty"`
+	BlueHourMorning   *AstroIntervalData `json:"blue_hour_morning,omitempty"`
+	BlueHourEvening   *AstroIntervalData `json:"blue_hour_evening,omitempty"`
+	Moonrise          string             `json:"moonrise,omitempty"`
+	Moonset           string             `json:"moonset,omitempty"`
+	MoonIllumination  float64            `json:"moon_illumination"`
+	MoonPhase         string             `json:"moon_phase"`
+	MoonPhaseName     string             `json:"moon_phase_name"`
+}
+
+// AstronomyResult resultado estructurado de get_astronomy
+type AstronomyResult struct {
+	Location    AstroPlace       `json:"location"`
+	Day         AstronomyDayData `json:"day"`
+	SunPosition *SunPositionData `json:"sun_position,omitempty"`
+}
+
+// SunPositionData posición del Sol en un instante
+type SunPositionData struct {
+	Time      string  `json:"time"`
+	Elevation float64 `json:"elevation"`
+	Azimuth   float64 `json:"azimuth"`
+}
+
 // GetAstronomySimple obtiene datos astronómicos de forma simplificada
-func GetAstronomySimple(cfg *config.Config, params map[string]interface{}) (interface{}, error) {
-	location, ok := params["location"].(string)
-	if !ok || location == "" {
-		return nil, fmt.Errorf("parámetro 'location' es requerido")
+func GetAstronomySimple(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error) {
+	tr, err := langParam(cfg, params)
+	if err != nil {
+		return nil, err
 	}
 
-	// Fecha opcional (por defecto hoy)
-	date := time.Now().Format("2006-01-02")
+	location, err := requiredString(tr, params, "location")
+	if err != nil {
+		return nil, err
+	}
+
+	// Fecha opcional (por defecto hoy en la ubicación, que se sabe al resolverla)
+	date := ""
 	if dateParam, exists := params["date"]; exists {
 		if dateStr, ok := dateParam.(string); ok && dateStr != "" {
 			// Validar formato de fecha
 			if _, err := time.Parse("2006-01-02", dateStr); err != nil {
-				return nil, fmt.Errorf("formato de fecha inválido. Use YYYY-MM-DD")
+				return nil, tr.Errorf("error.date_format", "date")
 			}
 			date = dateStr
 		}
 	}
 
-	// Construir URL
-	baseURL := fmt.Sprintf("%s/astronomy.json", cfg.BaseURL)
-	params_url := url.Values{}
-	params_url.Add("key", cfg.WeatherAPIKey)
-	params_url.Add("q", location)
-	params_url.Add("dt", date)
+	// La consulta a WeatherAPI solo resuelve la ubicación: sin fecha vale la del servidor
+	queryDate := date
+	if queryDate == "" {
+		queryDate = time.Now().Format("2006-01-02")
+	}
+	place, err := resolveAstroPlace(ctx, cfg, tr, location, queryDate, stringParam(params, "timezone", ""))
+	if err != nil {
+		return nil, err
+	}
+	if date == "" {
+		date = time.Now().In(place.loc).Format("2006-01-02")
+	}
+
+	day, _ := time.ParseInLocation("2006-01-02", date, place.loc)
+
+	// Rango opcional: tabla día por día hasta end_date
+	if endDate := stringParam(params, "end_date", ""); endDate != "" {
+		end, err := time.ParseInLocation("2006-01-02", endDate, place.loc)
+		if err != nil {
+			return nil, tr.Errorf("error.date_format", "end_date")
+		}
+		return astronomyRange(ctx, tr, place, day, end)
+	}
+
+	ephemeris := astronomy.ComputeDay(day, place.Lat, place.Lon)
 
-	fullURL := fmt.Sprintf("%s?%s", baseURL, params_url.Encode())
+	result := &AstronomyResult{
+		Location: *place,
+		Day:      astronomyDayData(tr, ephemeris, place.loc),
+	}
 
-	// Hacer request
-	resp, err := http.Get(fullURL)
-	if err != nil {
-		return nil, fmt.Errorf("error conectando con WeatherAPI: %v", err)
+	// Posición actual del Sol si la fecha consultada es hoy
+	now := time.Now().In(place.loc)
+	if now.Format("2006-01-02") == date {
+		elevation, azimuth := astronomy.SunPosition(now, place.Lat, place.Lon)
+		result.SunPosition = &SunPositionData{
+			Time:      now.Format("15:04"),
+			Elevation: roundTo(elevation, 1),
+			Azimuth:   roundTo(azimuth, 1),
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error de WeatherAPI (código %d): verificar ubicación, fecha y API key", resp.StatusCode)
+	return &ToolResult{
+		Structured: result,
+		Document:   astronomyDocument(tr, result),
+	}, nil
+}
+
+// resolveAstroPlace obtiene coordenadas y zona horaria de WeatherAPI; si la API no
+// responde y la ubicación son coordenadas, sigue sin conexión con la zona indicada,
+// la del gazetteer o una aproximada por longitud
+func resolveAstroPlace(ctx context.Context, cfg *config.Config, tr i18n.Translator, location, date, timezone string) (*AstroPlace, error) {
+	astroResp, apiErr := fetchAstronomyAPI(ctx, cfg, tr, location, date)
+	if apiErr == nil {
+		place := &AstroPlace{
+			LocationIdentity: locationIdentity(ctx),
+			Name:             astroResp.Location.Name,
+			Region:           astroResp.Location.Region,
+			Country:          astroResp.Location.Country,
+			Lat:              astroResp.Location.Lat,
+			Lon:              astroResp.Location.Lon,
+			TzID:             astroResp.Location.TzID,
+			Localtime:        astroResp.Location.Localtime,
+			Source:           "weatherapi",
+		}
+		if timezone == "" {
+			timezone = place.TzID
+		}
+		place.loc, place.TzID = loadAstroLocation(timezone, place.Lon)
+		return place, nil
 	}
 
-	// Decodificar respuesta
+	lat, lon, ok := parseLatLon(location)
+	if !ok {
+		return nil, apiErr
+	}
+
+	place := &AstroPlace{
+		LocationIdentity: locationIdentity(ctx),
+		Name:             location,
+		Lat:              lat,
+		Lon:              lon,
+		Source:           "local",
+	}
+	if resolved := canonicalFrom(ctx); resolved != nil {
+		place.Name, place.Region, place.Country = resolved.Name, resolved.Region, resolved.Country
+		if timezone == "" {
+			timezone = resolved.TzID
+		}
+	}
+	place.loc, place.TzID = loadAstroLocation(timezone, lon)
+	return place, nil
+}
+
+// fetchAstronomyAPI consulta astronomy.json de WeatherAPI
+func fetchAstronomyAPI(ctx context.Context, cfg *config.Config, tr i18n.Translator, location, date string) (*SimpleAstronomyResponse, error) {
+	query := url.Values{}
+	query.Add("q", location)
+	query.Add("dt", date)
+
 	var astroResp SimpleAstronomyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&astroResp); err != nil {
-		return nil, fmt.Errorf("error decodificando respuesta: %v", err)
-	}
-
-	// Formatear respuesta legible
-	result := fmt.Sprintf(`🌌 DATOS ASTRONÓMICOS
-📍 Ubicación: %s, %s, %s
-📅 Fecha: %s
-🌐 Coordenadas: %.2f, %.2f
-🕐 Hora local: %s
-
-🌅 DATOS SOLARES
-• Amanecer: %s
-• Atardecer: %s
-
-🌙 DATOS LUNARES
-• Salida de luna: %s
-• Puesta de luna: %s
-• Fase lunar: %s
-
-💡 Zona horaria: %s`,
-		astroResp.Location.Name,
-		astroResp.Location.Region,
-		astroResp.Location.Country,
-		date,
-		astroResp.Location.Lat,
-		astroResp.Location.Lon,
-		astroResp.Location.Localtime,
-		astroResp.Astronomy.Astro.Sunrise,
-		astroResp.Astronomy.Astro.Sunset,
-		astroResp.Astronomy.Astro.Moonrise,
-		astroResp.Astronomy.Astro.Moonset,
-		astroResp.Astronomy.Astro.MoonPhase,
-		astroResp.Location.TzID,
-	)
-
-	return result, nil
+	if err := getWeatherAPI(ctx, cfg, tr, "astronomy.json", query, &astroResp); err != nil {
+		return nil, err
+	}
+	return &astroResp, nil
+}
+
+// loadAstroLocation carga una zona horaria IANA; si no existe usa un desfase fijo según la longitud
+func loadAstroLocation(timezone string, lon float64) (*time.Location, string) {
+	if timezone != "" {
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			return loc, timezone
+		}
+	}
+
+	offset := int(lon/15 + 0.5)
+	if lon < 0 {
+		offset = int(lon/15 - 0.5)
+	}
+	name := fmt.Sprintf("UTC%+d", offset)
+	return time.FixedZone(name, offset*3600), name
+}
+
+// astronomyDayData convierte las efemérides del motor a hora local
+func astronomyDayData(tr i18n.Translator, d astronomy.Day, loc *time.Location) AstronomyDayData {
+	clock := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.In(loc).Format("15:04")
+	}
+	span := func(i *astronomy.Interval) *AstroIntervalData {
+		if i == nil {
+			return nil
+		}
+		return &AstroIntervalData{Start: clock(&i.Start), End: clock(&i.End)}
+	}
+
+	minutes := int(d.DayLength.Minutes() + 0.5)
+	return AstronomyDayData{
+		Date:              d.Date.Format("2006-01-02"),
+		Sunrise:           clock(d.Sunrise),
+		Sunset:            clock(d.Sunset),
+		SolarNoon:         clock(&d.SolarNoon),
+		NoonElevation:     roundTo(d.NoonElevation, 1),
+		DayLength:         fmt.Sprintf("%dh %02dm", minutes/60, minutes%60),
+		DayLengthMinutes:  minutes,
+		PolarDay:          d.PolarDay,
+		PolarNight:        d.PolarNight,
+		CivilDawn:         clock(d.CivilDawn),
+		CivilDusk:         clock(d.CivilDusk),
+		NauticalDawn:      clock(d.NauticalDawn),
+		NauticalDusk:      clock(d.NauticalDusk),
+		AstronomicalDawn:  clock(d.AstronomicalDawn),
+		AstronomicalDusk:  clock(d.AstronomicalDusk),
+		GoldenHourMorning: span(d.GoldenHourMorning),
+		GoldenHourEvening: span(d.GoldenHourEvening),
+		BlueHourMorning:   span(d.BlueHourMorning),
+		BlueHourEvening:   span(d.BlueHourEvening),
+		Moonrise:          clock(d.Moonrise),
+		Moonset:           clock(d.Moonset),
+		MoonIllumination:  roundTo(d.MoonIllumination*100, 0),
+		MoonPhase:         d.MoonPhase.Key,
+		MoonPhaseName:     tr.T("moon." + d.MoonPhase.Key),
+	}
+}
+
+// astronomyDocument arma la presentación de get_astronomy
+func astronomyDocument(tr i18n.Translator, r *AstronomyResult) *render.Document {
+	d := r.Day
+	spanText := func(i *AstroIntervalData) string {
+		if i == nil {
+			return "—"
+		}
+		return i.Start + " - " + i.End
+	}
+
+	doc := &render.Document{
+		Icon:  "🌌",
+		Title: tr.T("astronomy.title"),
+		Summary: tr.T("astronomy.summary", r.Location.label(), d.Date, orDash(d.Sunrise), orDash(d.Sunset),
+			d.DayLength, d.MoonPhaseName, d.MoonIllumination),
+		Fields: []render.Field{
+			{Icon: "📍", Label: tr.T("label.location"), Value: r.Location.label()},
+			{Icon: "📅", Label: tr.T("label.date"), Value: d.Date},
+			{Icon: "🌐", Label: tr.T("label.coordinates"), Value: fmt.Sprintf("%.2f, %.2f", r.Location.Lat, r.Location.Lon)},
+		},
+	}
+	if r.Location.Localtime != "" {
+		doc.Fields = append(doc.Fields, render.Field{Icon: "🕐", Label: tr.T("label.local_time"), Value: r.Location.Localtime})
+	}
+
+	sun := render.Section{
+		Icon:  "🌅",
+		Title: tr.T("astronomy.sun"),
+		Fields: []render.Field{
+			{Label: tr.T("label.sunrise"), Value: orDash(d.Sunrise)},
+			{Label: tr.T("label.sunset"), Value: orDash(d.Sunset)},
+			{Label: tr.T("label.solar_noon"), Value: tr.T("astronomy.noon", d.SolarNoon, d.NoonElevation)},
+			{Label: tr.T("label.day_length"), Value: d.DayLength},
+		},
+	}
+	if d.PolarDay {
+		sun.Fields = append(sun.Fields, render.Field{Icon: "☀️", Value: tr.T("astronomy.polar_day")})
+	}
+	if d.PolarNight {
+		sun.Fields = append(sun.Fields, render.Field{Icon: "🌑", Value: tr.T("astronomy.polar_night")})
+	}
+	if p := r.SunPosition; p != nil {
+		sun.Fields = append(sun.Fields, render.Field{
+			Label: tr.T("astronomy.sun_position", p.Time),
+			Value: tr.T("astronomy.sun_position_value", p.Elevation, p.Azimuth),
+		})
+	}
+
+	doc.Sections = []render.Section{
+		sun,
+		{
+			Icon:  "🌄",
+			Title: tr.T("astronomy.twilight"),
+			Fields: []render.Field{
+				{Label: tr.T("label.civil"), Value: orDash(d.CivilDawn) + " / " + orDash(d.CivilDusk)},
+				{Label: tr.T("label.nautical"), Value: orDash(d.NauticalDawn) + " / " + orDash(d.NauticalDusk)},
+				{Label: tr.T("label.astronomical"), Value: orDash(d.AstronomicalDawn) + " / " + orDash(d.AstronomicalDusk)},
+			},
+		},
+		{
+			Icon:  "📸",
+			Title: tr.T("astronomy.photo_light"),
+			Fields: []render.Field{
+				{Label: tr.T("label.blue_hour_morning"), Value: spanText(d.BlueHourMorning)},
+				{Label: tr.T("label.golden_hour_morning"), Value: spanText(d.GoldenHourMorning)},
+				{Label: tr.T("label.golden_hour_evening"), Value: spanText(d.GoldenHourEvening)},
+				{Label: tr.T("label.blue_hour_evening"), Value: spanText(d.BlueHourEvening)},
+			},
+		},
+		{
+			Icon:  "🌙",
+			Title: tr.T("astronomy.moon"),
+			Fields: []render.Field{
+				{Label: tr.T("label.moonrise"), Value: orDash(d.Moonrise)},
+				{Label: tr.T("label.moonset"), Value: orDash(d.Moonset)},
+				{Label: tr.T("label.moon_phase"), Value: tr.T("astronomy.phase", d.MoonPhaseName, d.MoonIllumination)},
+			},
+		},
+	}
+
+	doc.Notes = []render.Field{{Icon: "💡", Label: tr.T("label.timezone"), Value: r.Location.TzID}}
+	if r.Location.Source == "local" {
+		doc.Notes = append(doc.Notes, render.Field{Icon: "⚠️", Value: tr.T("astronomy.offline")})
+	}
+
+	return doc
+}
+
+// roundTo redondea a la cantidad de decimales indicada
+func roundTo(value float64, decimals int) float64 {
+	pow := math.Pow(10, float64(decimals))
+	return math.Round(value*pow) / pow
 }
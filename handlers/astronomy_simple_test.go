@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"weather-mcp-server/config"
+)
+
+func TestGetAstronomyDefaultDate(t *testing.T) {
+	// Sin WeatherAPI: las coordenadas se resuelven sin conexión con la zona indicada.
+	// Kiritimati (UTC+14) y Pago Pago (UTC-11) nunca están en el mismo día, así que al
+	// menos una difiere de la fecha del servidor
+	cfg := &config.Config{BaseURL: "http://127.0.0.1:1", RateLimit: 60}
+	for _, tz := range []string{"Pacific/Kiritimati", "Pacific/Pago_Pago"} {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			t.Skip("sin base de zonas horarias:", err)
+		}
+		params := map[string]interface{}{"location": "1.87,-157.4", "timezone": tz}
+		before := time.Now().In(loc).Format("2006-01-02")
+		result, err := GetAstronomySimple(context.Background(), cfg, params)
+		if err != nil {
+			t.Fatalf("%s: %v", tz, err)
+		}
+		after := time.Now().In(loc).Format("2006-01-02")
+
+		got := result.(*ToolResult).Structured.(*AstronomyResult)
+		if got.Day.Date != before && got.Day.Date != after {
+			t.Errorf("%s: fecha %s, se esperaba la de hoy en la zona (%s)", tz, got.Day.Date, before)
+		}
+		if got.SunPosition == nil {
+			t.Errorf("%s: falta la posición actual del Sol para la fecha de hoy", tz)
+		}
+	}
+}
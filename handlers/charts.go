@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"math"
+
+	"weather-mcp-server/chart"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+	"weather-mcp-server/units"
+)
+
+// Resoluciones del gráfico de get_forecast
+const (
+	chartIntervalDay  = "day"
+	chartIntervalHour = "hour"
+)
+
+// attachChart dibuja el gráfico en el formato pedido y lo agrega a las imágenes del resultado
+func (r *ToolResult) attachChart(format string, s chart.Series) error {
+	data, mimeType, err := chart.Render(format, s)
+	if err != nil {
+		return err
+	}
+	r.Images = append(r.Images, ToolImage{MimeType: mimeType, Data: data})
+	return nil
+}
+
+// dailyChart arma el gráfico diario: máximas y mínimas, precipitación total y viento
+// máximo con la dirección de la hora más ventosa
+func dailyChart(tr i18n.Translator, place string, days []models.ForecastDay, system units.System) chart.Series {
+	s := chart.Series{
+		Title:        tr.T("chart.daily_title", place),
+		TempLabel:    tr.T("chart.temp_max", system.Temperature),
+		TempLowLabel: tr.T("chart.temp_min", system.Temperature),
+		PrecipLabel:  tr.T("chart.precip", system.Precip),
+		WindLabel:    tr.T("chart.wind", system.Speed),
+	}
+	for _, day := range days {
+		direction := math.NaN()
+		strongest := -1.0
+		for _, hour := range day.Hour {
+			if hour.WindKph > strongest {
+				strongest, direction = hour.WindKph, float64(hour.WindDegree)
+			}
+		}
+
+		s.Labels = append(s.Labels, shortDate(day.Date))
+		s.Temp = append(s.Temp, system.Temp(day.Day.MaxtempC))
+		s.TempLow = append(s.TempLow, system.Temp(day.Day.MintempC))
+		s.Precip = append(s.Precip, system.PrecipValue(day.Day.TotalprecipMm))
+		s.Wind = append(s.Wind, roundTo(system.SpeedValue(day.Day.MaxwindKph), 0))
+		s.WindDeg = append(s.WindDeg, direction)
+	}
+	return s
+}
+
+// hourlyChart arma el gráfico por hora: temperatura, precipitación y viento.
+// Las etiquetas muestran la hora y la fecha a medianoche
+func hourlyChart(tr i18n.Translator, place string, hours []models.HourInfo, system units.System) chart.Series {
+	s := chart.Series{
+		Title:       tr.T("chart.hourly_title", place),
+		TempLabel:   tr.T("chart.temp", system.Temperature),
+		PrecipLabel: tr.T("chart.precip", system.Precip),
+		WindLabel:   tr.T("chart.wind", system.Speed),
+	}
+	for _, hour := range hours {
+		label := hour.Time
+		if len(label) == len(localTimeLayout) {
+			label = label[11:]
+			if label == "00:00" {
+				label = shortDate(hour.Time[:10])
+			}
+		}
+
+		s.Labels = append(s.Labels, label)
+		s.Temp = append(s.Temp, system.Temp(hour.TempC))
+		s.Precip = append(s.Precip, system.PrecipValue(hour.PrecipMm))
+		s.Wind = append(s.Wind, roundTo(system.SpeedValue(hour.WindKph), 0))
+		s.WindDeg = append(s.WindDeg, float64(hour.WindDegree))
+	}
+	return s
+}
+
+// shortDate "2006-01-02" → "01-02"
+func shortDate(date string) string {
+	if len(date) == len("2006-01-02") {
+		return date[5:]
+	}
+	return date
+}
@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+	"weather-mcp-server/render"
+	"weather-mcp-server/units"
+)
+
+const (
+	// maxCompareLocations límite de ubicaciones por comparación
+	maxCompareLocations = 10
+	// maxCompareConcurrency límite de requests simultáneos a WeatherAPI
+	maxCompareConcurrency = 4
+)
+
+// compareMetric métrica que se puede comparar entre ubicaciones. Los valores se
+// extraen en métrico y se convierten según su magnitud (Kind); el nombre legible
+// es la clave "metric.<Key>" del catálogo
+type compareMetric struct {
+	Key     string
+	Kind    string // temperature, speed, precipitation, distance, percent o index
+	current func(c models.CurrentInfo) float64
+	daily   func(d models.DayInfo) float64
+}
+
+// convert convierte un valor métrico al sistema de unidades
+func (m compareMetric) convert(system units.System, value float64) float64 {
+	switch m.Kind {
+	case "temperature":
+		return system.Temp(value)
+	case "speed":
+		return system.SpeedValue(value)
+	case "precipitation":
+		return system.PrecipValue(value)
+	case "distance":
+		return system.DistanceValue(value)
+	}
+	return value
+}
+
+// unit devuelve la unidad de la métrica en el sistema indicado
+func (m compareMetric) unit(system units.System) string {
+	switch m.Kind {
+	case "temperature":
+		return system.Temperature
+	case "speed":
+		return system.Speed
+	case "precipitation":
+		return system.Precip
+	case "distance":
+		return system.Distance
+	case "percent":
+		return "%"
+	}
+	return ""
+}
+
+// compareMetrics métricas disponibles, en el orden por defecto
+var compareMetrics = []compareMetric{
+	{
+		Key: "temperature", Kind: "temperature",
+		current: func(c models.CurrentInfo) float64 { return c.TempC },
+		daily:   func(d models.DayInfo) float64 { return d.MaxtempC },
+	},
+	{
+		Key: "humidity", Kind: "percent",
+		current: func(c models.CurrentInfo) float64 { return float64(c.Humidity) },
+		daily:   func(d models.DayInfo) float64 { return d.Avghumidity },
+	},
+	{
+		Key: "wind", Kind: "speed",
+		current: func(c models.CurrentInfo) float64 { return c.WindKph },
+		daily:   func(d models.DayInfo) float64 { return d.MaxwindKph },
+	},
+	{
+		Key: "precipitation", Kind: "precipitation",
+		current: func(c models.CurrentInfo) float64 { return c.PrecipMm },
+		daily:   func(d models.DayInfo) float64 { return d.TotalprecipMm },
+	},
+	{
+		Key: "uv", Kind: "index",
+		current: func(c models.CurrentInfo) float64 { return c.UV },
+		daily:   func(d models.DayInfo) float64 { return d.UV },
+	},
+	{
+		Key: "visibility", Kind: "distance",
+		current: func(c models.CurrentInfo) float64 { return c.VisKm },
+		daily:   func(d models.DayInfo) float64 { return d.Avgvis_km },
+	},
+}
+
+// ComparisonRow fila de la comparación para una ubicación
+type ComparisonRow struct {
+	LocationIdentity
+	Rank      int                `json:"rank,omitempty"`
+	Query     string             `json:"query"`
+	Name      string             `json:"name,omitempty"`
+	Region    string             `json:"region,omitempty"`
+	Country   string             `json:"country,omitempty"`
+	Lat       float64            `json:"lat,omitempty"`
+	Lon       float64            `json:"lon,omitempty"`
+	Date      string             `json:"date,omitempty"`
+	Condition string             `json:"condition,omitempty"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+	Error     string             `json:"error,omitempty"`
+	// Alternatives otras ubicaciones con el mismo nombre si la consulta era ambigua
+	Alternatives []LocationCandidate `json:"alternatives,omitempty"`
+}
+
+// ComparisonResult resultado estructurado de compare_weather
+type ComparisonResult struct {
+	Day     *int            `json:"day,omitempty"`
+	Units   units.System    `json:"units"`
+	SortBy  string          `json:"sort_by"`
+	Order   string          `json:"order"`
+	Metrics []string        `json:"metrics"`
+	Rows    []ComparisonRow `json:"rows"`
+}
+
+// CompareWeather compara el clima de varias ubicaciones (actual o de un día del pronóstico)
+func CompareWeather(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error) {
+	tr, err := langParam(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := stringListParam(tr, params, "locations")
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) < 2 {
+		return nil, tr.Errorf("error.min_items", "locations", 2)
+	}
+	if len(locations) > maxCompareLocations {
+		return nil, tr.Errorf("error.max_items", "locations", maxCompareLocations)
+	}
+
+	metricKeys, err := stringListParam(tr, params, "metrics")
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := selectCompareMetrics(tr, metricKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy := metrics[0]
+	if key := stringParam(params, "sort_by", ""); key != "" {
+		found := false
+		for _, m := range compareMetrics {
+			if m.Key == key {
+				sortBy, found = m, true
+				break
+			}
+		}
+		if !found {
+			return nil, tr.Errorf("error.invalid_value", "sort_by", key)
+		}
+	}
+
+	system, err := unitsParam(cfg, tr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	order := stringParam(params, "order", "desc")
+	if order != "desc" && order != "asc" {
+		return nil, tr.Errorf("error.order")
+	}
+
+	// Día opcional del pronóstico (0 = hoy); sin día se usa el clima actual
+	var day *int
+	if _, exists := params["day"]; exists {
+		d := intParam(params, "day", 0)
+		if d < 0 || d > 9 {
+			return nil, tr.Errorf("error.range", "day", 0, 9)
+		}
+		day = &d
+	}
+
+	rows := fetchComparisonRows(ctx, cfg, tr, locations, metrics, day, system)
+	rankComparisonRows(rows, sortBy.Key, order)
+
+	result := &ComparisonResult{
+		Day:    day,
+		Units:  system,
+		SortBy: sortBy.Key,
+		Order:  order,
+		Rows:   rows,
+	}
+	for _, m := range metrics {
+		result.Metrics = append(result.Metrics, m.Key)
+	}
+
+	failed := 0
+	for _, row := range rows {
+		if row.Error != "" {
+			failed++
+		}
+	}
+	if failed == len(rows) {
+		return nil, tr.Errorf("compare.error_all_failed", rows[0].Error)
+	}
+
+	toolResult := &ToolResult{
+		Structured: result,
+		Document:   comparisonDocument(tr, result, metrics, sortBy),
+	}
+	if boolParam(params, "geojson", false) {
+		var names []string
+		for _, row := range result.Rows {
+			names = append(names, row.Query)
+		}
+		if err := toolResult.attachGeoJSON(tr, "compare", names, comparisonGeoJSON(result)); err != nil {
+			return nil, err
+		}
+	}
+	return toolResult, nil
+}
+
+// comparisonGeoJSON un punto por ubicación comparada con sus métricas como propiedades;
+// las ubicaciones con error no tienen coordenadas y se omiten
+func comparisonGeoJSON(r *ComparisonResult) *GeoJSONFeatureCollection {
+	c := newFeatureCollection()
+	for _, row := range r.Rows {
+		if row.Error != "" {
+			continue
+		}
+		properties := map[string]interface{}{
+			"rank":      row.Rank,
+			"query":     row.Query,
+			"name":      row.Name,
+			"region":    row.Region,
+			"country":   row.Country,
+			"condition": row.Condition,
+			"units":     r.Units.Name,
+		}
+		if row.Date != "" {
+			properties["date"] = row.Date
+		}
+		for key, value := range row.Metrics {
+			properties[key] = value
+		}
+		c.addPoint(row.Lat, row.Lon, properties)
+	}
+	return c
+}
+
+// selectCompareMetrics valida las métricas pedidas; sin métricas se usan todas
+func selectCompareMetrics(tr i18n.Translator, keys []string) ([]compareMetric, error) {
+	if len(keys) == 0 {
+		return compareMetrics, nil
+	}
+
+	var selected []compareMetric
+	for _, key := range keys {
+		found := false
+		for _, m := range compareMetrics {
+			if m.Key == key {
+				selected = append(selected, m)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, tr.Errorf("compare.error_metric", key)
+		}
+	}
+	return selected, nil
+}
+
+// fetchComparisonRows consulta todas las ubicaciones en paralelo con concurrencia acotada
+func fetchComparisonRows(ctx context.Context, cfg *config.Config, tr i18n.Translator, locations []string, metrics []compareMetric, day *int, system units.System) []ComparisonRow {
+	rows := make([]ComparisonRow, len(locations))
+	progress := newProgress(ctx, len(locations))
+	sem := make(chan struct{}, maxCompareConcurrency)
+	var wg sync.WaitGroup
+
+	for i, location := range locations {
+		wg.Add(1)
+		go func(i int, location string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			rows[i] = fetchComparisonRow(ctx, cfg, tr, location, metrics, day, system)
+			progress.step(tr.T("progress.location", location))
+		}(i, location)
+	}
+
+	wg.Wait()
+	return rows
+}
+
+// fetchComparisonRow obtiene la fila de una ubicación; los errores quedan en la fila
+func fetchComparisonRow(ctx context.Context, cfg *config.Config, tr i18n.Translator, location string, metrics []compareMetric, day *int, system units.System) ComparisonRow {
+	row := ComparisonRow{Query: location, Metrics: map[string]float64{}}
+
+	// Cada ubicación se resuelve a su identidad canónica; si no se puede, se consulta
+	// tal cual. Las ambiguas no se preguntan (la comparación consulta todas a la vez):
+	// se usa la primera y las demás quedan en la fila
+	if resolved, _, err := resolveLocation(ctx, cfg, tr, location, false); err == nil && resolved != nil {
+		row.LocationIdentity = resolved.LocationIdentity
+		row.Alternatives = resolved.Alternatives
+		location = resolved.Ref
+	}
+
+	var info models.LocationInfo
+	if day == nil {
+		resp, err := fetchCurrent(ctx, cfg, tr, location)
+		if err != nil {
+			row.Error = err.Error()
+			return row
+		}
+		info = resp.Location
+		row.Date = resp.Current.LastUpdated
+		row.Condition = resp.Current.Condition.Text
+		for _, m := range metrics {
+			row.Metrics[m.Key] = roundTo(m.convert(system, m.current(resp.Current)), 2)
+		}
+	} else {
+		resp, err := fetchForecast(ctx, cfg, tr, location, *day+1)
+		if err != nil {
+			row.Error = err.Error()
+			return row
+		}
+		if len(resp.Forecast.Forecastday) <= *day {
+			row.Error = tr.T("compare.error_day", *day)
+			return row
+		}
+		info = resp.Location
+		forecastDay := resp.Forecast.Forecastday[*day]
+		row.Date = forecastDay.Date
+		row.Condition = forecastDay.Day.Condition.Text
+		for _, m := range metrics {
+			row.Metrics[m.Key] = roundTo(m.convert(system, m.daily(forecastDay.Day)), 2)
+		}
+	}
+
+	row.Name = info.Name
+	row.Region = info.Region
+	row.Country = info.Country
+	row.Lat = info.Lat
+	row.Lon = info.Lon
+	return row
+}
+
+// rankComparisonRows ordena las filas por la métrica indicada; las filas con error van al final
+func rankComparisonRows(rows []ComparisonRow, key string, order string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if (rows[i].Error == "") != (rows[j].Error == "") {
+			return rows[i].Error == ""
+		}
+		if order == "asc" {
+			return rows[i].Metrics[key] < rows[j].Metrics[key]
+		}
+		return rows[i].Metrics[key] > rows[j].Metrics[key]
+	})
+
+	for i := range rows {
+		if rows[i].Error == "" {
+			rows[i].Rank = i + 1
+		}
+	}
+}
+
+// comparisonDocument arma la tabla de la comparación
+func comparisonDocument(tr i18n.Translator, result *ComparisonResult, metrics []compareMetric, sortBy compareMetric) *render.Document {
+	title := tr.T("compare.title_current")
+	if result.Day != nil {
+		title = tr.T("compare.title_day", *result.Day)
+	}
+	direction := tr.T("compare.order_desc")
+	if result.Order == "asc" {
+		direction = tr.T("compare.order_asc")
+	}
+	sortLabel := strings.ToLower(tr.T("metric." + sortBy.Key))
+
+	table := &render.Table{Columns: []string{"#", tr.T("label.location"), tr.T("label.date"), tr.T("label.condition")}}
+	for _, m := range metrics {
+		label := tr.T("metric." + m.Key)
+		if unit := m.unit(result.Units); unit != "" {
+			label = fmt.Sprintf("%s (%s)", label, unit)
+		}
+		table.Columns = append(table.Columns, label)
+	}
+
+	var failures, ranking []string
+	var notes []render.Field
+	for _, row := range result.Rows {
+		if len(row.Alternatives) > 0 {
+			label := row.Query
+			if row.Name != "" {
+				label = placeLabel(PlaceData{Name: row.Name, Region: row.Region, Country: row.Country})
+			}
+			notes = append(notes, alternativesNote(tr, label, row.Alternatives))
+		}
+		if row.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", row.Query, row.Error))
+			continue
+		}
+		cells := []string{
+			fmt.Sprintf("%d.", row.Rank),
+			fmt.Sprintf("%s, %s", row.Name, row.Country),
+			row.Date,
+			row.Condition,
+		}
+		for _, m := range metrics {
+			cells = append(cells, formatMetricValue(row.Metrics[m.Key]))
+		}
+		table.Rows = append(table.Rows, cells)
+		ranking = append(ranking, fmt.Sprintf("%d. %s %s", row.Rank, row.Name, formatMetricValue(row.Metrics[sortBy.Key])))
+	}
+
+	doc := &render.Document{
+		Icon:  "📊",
+		Title: tr.T("compare.title", title),
+		Summary: tr.T("compare.summary", title, sortLabel, direction) + ": " +
+			strings.Join(ranking, ", "),
+		Fields: []render.Field{
+			{Icon: "📏", Label: tr.T("label.sorted_by"), Value: fmt.Sprintf("%s (%s)", sortLabel, direction)},
+		},
+		Sections: []render.Section{{Table: table}},
+		Notes:    notes,
+	}
+	if len(failures) > 0 {
+		doc.Sections = append(doc.Sections, render.Section{
+			Icon:  "⚠️",
+			Title: tr.T("compare.failures"),
+			List:  failures,
+		})
+	}
+	return doc
+}
+
+// formatMetricValue formatea un valor numérico de la tabla sin decimales innecesarios
+func formatMetricValue(value float64) string {
+	if value == math.Trunc(value) {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.1f", value)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/models"
+)
+
+func TestCompareWeatherAmbiguous(t *testing.T) {
+	// WeatherAPI simulada: "Valencia" coincide con dos ubicaciones y "Sevilla" con una
+	places := map[string][]models.LocationSearchResult{
+		"valencia": {
+			{ID: 100, Name: "Valencia", Region: "Valenciana", Country: "Spain", Lat: 39.47, Lon: -0.38},
+			{ID: 101, Name: "Valencia", Region: "Carabobo", Country: "Venezuela", Lat: 10.16, Lon: -68},
+		},
+		"sevilla": {
+			{ID: 200, Name: "Sevilla", Region: "Andalucia", Country: "Spain", Lat: 37.38, Lon: -5.98},
+		},
+	}
+	byID := map[string]models.LocationSearchResult{}
+	for _, results := range places {
+		for _, r := range results {
+			byID[fmt.Sprintf("id:%d", r.ID)] = r
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/search.json"):
+			json.NewEncoder(w).Encode(places[strings.ToLower(q)])
+		case strings.HasSuffix(r.URL.Path, "/current.json"):
+			place, ok := byID[q]
+			if !ok {
+				http.Error(w, `{"error":{"code":1006,"message":"No matching location found."}}`, http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"location": map[string]interface{}{"name": place.Name, "region": place.Region, "country": place.Country, "lat": place.Lat, "lon": place.Lon},
+				"current":  map[string]interface{}{"last_updated": "2026-10-19 12:00", "temp_c": 20, "condition": map[string]interface{}{"text": "Sunny"}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{BaseURL: server.URL, WeatherAPIKey: "test", RateLimit: 60}
+	params := map[string]interface{}{"locations": []interface{}{"Valencia", "Sevilla"}, "metrics": []interface{}{"temperature"}}
+	result, err := CompareWeather(context.Background(), cfg, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toolResult := result.(*ToolResult)
+	comparison := toolResult.Structured.(*ComparisonResult)
+
+	rows := map[string]ComparisonRow{}
+	for _, row := range comparison.Rows {
+		rows[row.Query] = row
+	}
+	valencia := rows["Valencia"]
+	if valencia.ID != 100 || valencia.Country != "Spain" {
+		t.Errorf("Valencia: se esperaba la primera coincidencia (id 100), se obtuvo %+v", valencia)
+	}
+	if len(valencia.Alternatives) != 1 || valencia.Alternatives[0].Location != "id:101" {
+		t.Errorf("Valencia: se esperaba id:101 como alternativa, se obtuvo %+v", valencia.Alternatives)
+	}
+	if sevilla := rows["Sevilla"]; sevilla.ID != 200 || len(sevilla.Alternatives) != 0 {
+		t.Errorf("Sevilla: se esperaba id 200 sin alternativas, se obtuvo %+v", sevilla)
+	}
+
+	var notes []string
+	for _, note := range toolResult.Document.Notes {
+		if note.Icon == "🔀" {
+			notes = append(notes, note.Value)
+		}
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "id:101") {
+		t.Errorf("se esperaba una nota 🔀 con id:101, se obtuvo %q", notes)
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+	"weather-mcp-server/render"
+	"weather-mcp-server/units"
+)
+
+// ErrCompletionRefNotFound se devuelve al completar un argumento de un prompt o
+// plantilla de recurso que no existe
+var ErrCompletionRefNotFound = errors.New("referencia de completado no encontrada")
+
+const (
+	// maxCompletionValues valores por respuesta como máximo (límite del protocolo)
+	maxCompletionValues = 100
+	// minCompletionSearch caracteres escritos a partir de los que se consulta search.json
+	minCompletionSearch = 3
+	// maxRecentLocations ubicaciones recientes que se recuerdan
+	maxRecentLocations = 20
+)
+
+// CompletionResult resultado de completion/complete
+type CompletionResult struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total"`
+	HasMore bool     `json:"hasMore"`
+}
+
+// completers sugerencias por nombre de argumento a partir de lo escrito; los
+// argumentos sin completer devuelven una lista vacía
+var completers = map[string]func(ctx context.Context, cfg *config.Config, tr i18n.Translator, value string) []string{
+	"location": completeLocation,
+	"query":    completeLocation,
+	"units":    enumCompleter(func(cfg *config.Config) []string { return units.Names() }),
+	"lang":     enumCompleter(func(cfg *config.Config) []string { return i18n.Languages() }),
+	"format":   enumCompleter(func(cfg *config.Config) []string { return render.Formats() }),
+	"activity": enumCompleter((*config.Config).ActivityNames),
+	"days": enumCompleter(func(cfg *config.Config) []string {
+		var days []string
+		for d := 1; d <= 10; d++ {
+			days = append(days, strconv.Itoa(d))
+		}
+		return days
+	}),
+	"date": enumCompleter(func(cfg *config.Config) []string {
+		dates := []string{"today"}
+		for d := 0; d < 7; d++ {
+			dates = append(dates, time.Now().AddDate(0, 0, d).Format("2006-01-02"))
+		}
+		return dates
+	}),
+}
+
+// enumCompleter completer de un argumento con valores fijos
+func enumCompleter(names func(cfg *config.Config) []string) func(ctx context.Context, cfg *config.Config, tr i18n.Translator, value string) []string {
+	return func(ctx context.Context, cfg *config.Config, tr i18n.Translator, value string) []string {
+		return matchingValues(names(cfg), value)
+	}
+}
+
+// Complete sugiere valores para un argumento de un prompt (ref/prompt) o de una
+// plantilla de recurso (ref/resource). Devuelve ErrCompletionRefNotFound si la
+// referencia no existe
+func Complete(ctx context.Context, cfg *config.Config, tr i18n.Translator, ref map[string]interface{}, argument, value string) (*CompletionResult, error) {
+	if !completionRefExists(ref) {
+		return nil, ErrCompletionRefNotFound
+	}
+
+	var values []string
+	if completer, ok := completers[argument]; ok {
+		values = completer(ctx, cfg, tr, value)
+	}
+
+	result := &CompletionResult{Values: values, Total: len(values)}
+	if len(values) > maxCompletionValues {
+		result.Values = values[:maxCompletionValues]
+		result.HasMore = true
+	}
+	if result.Values == nil {
+		result.Values = []string{}
+	}
+	return result, nil
+}
+
+// completionRefExists indica si la referencia apunta a un prompt o a una plantilla
+// de recurso registrados
+func completionRefExists(ref map[string]interface{}) bool {
+	switch ref["type"] {
+	case "ref/prompt":
+		name, _ := ref["name"].(string)
+		for _, p := range prompts {
+			if p.Name == name {
+				return true
+			}
+		}
+	case "ref/resource":
+		uri, _ := ref["uri"].(string)
+		for _, kind := range resourceKinds {
+			if kind.Template == uri {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchingValues filtra los candidatos que empiezan por lo escrito (sin distinguir
+// mayúsculas)
+func matchingValues(candidates []string, value string) []string {
+	prefix := strings.ToLower(strings.TrimSpace(value))
+
+	var values []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), prefix) {
+			values = append(values, candidate)
+		}
+	}
+	return values
+}
+
+// uniqueValues descarta los valores repetidos (sin distinguir mayúsculas)
+// conservando el orden
+func uniqueValues(values []string) []string {
+	seen := map[string]bool{}
+
+	var unique []string
+	for _, value := range values {
+		if key := strings.ToLower(value); !seen[key] {
+			seen[key] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// completeLocation sugiere primero las ubicaciones favoritas y las usadas
+// recientemente que empiezan por lo escrito y después las de search.json, que
+// también corrige nombres mal escritos. La búsqueda no espera turno en el límite de
+// llamadas: si no hay llamadas disponibles o falla, se sugieren solo las locales
+func completeLocation(ctx context.Context, cfg *config.Config, tr i18n.Translator, value string) []string {
+	local := append([]string{}, cfg.FavoriteLocations...)
+	local = append(local, cfg.ResourceLocations...)
+	local = append(local, recentLocations.list()...)
+	candidates := matchingValues(local, value)
+
+	query := strings.TrimSpace(value)
+	if len([]rune(query)) >= minCompletionSearch {
+		values := url.Values{}
+		values.Add("q", query)
+		var resp models.SearchLocationResponse
+		if err := requestWeatherAPI(ctx, cfg, tr, "search.json", values, &resp, false); err == nil {
+			for _, loc := range resp {
+				candidates = append(candidates, searchResultLabel(loc))
+			}
+		}
+	}
+	return uniqueValues(candidates)
+}
+
+// searchResultLabel nombre completo de un resultado de búsqueda, utilizable como
+// 'location' en las herramientas (ej: "Valencia, Carabobo, Venezuela")
+func searchResultLabel(loc models.LocationSearchResult) string {
+	parts := []string{loc.Name}
+	if loc.Region != "" && loc.Region != loc.Name {
+		parts = append(parts, loc.Region)
+	}
+	if loc.Country != "" {
+		parts = append(parts, loc.Country)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// recentLocations ubicaciones usadas en las últimas llamadas a herramientas, de la
+// más reciente a la más antigua
+var recentLocations = &locationHistory{}
+
+// locationHistory lista acotada de ubicaciones usadas
+type locationHistory struct {
+	mu        sync.Mutex
+	locations []string
+}
+
+// add registra una ubicación usada; si ya estaba pasa a ser la más reciente
+func (h *locationHistory) add(location string) {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	locations := []string{location}
+	for _, l := range h.locations {
+		if !strings.EqualFold(l, location) && len(locations) < maxRecentLocations {
+			locations = append(locations, l)
+		}
+	}
+	h.locations = locations
+}
+
+// list devuelve una copia de las ubicaciones recientes
+func (h *locationHistory) list() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string{}, h.locations...)
+}
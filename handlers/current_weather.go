@@ -1,20 +1,68 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"net/url"
 
 	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
 	"weather-mcp-server/models"
+	"weather-mcp-server/render"
+	"weather-mcp-server/units"
 )
 
+// PlaceData ubicación resuelta por WeatherAPI, con la identidad canónica si la
+// resolvió el resolver
+type PlaceData struct {
+	LocationIdentity
+	Name      string  `json:"name"`
+	Region    string  `json:"region,omitempty"`
+	Country   string  `json:"country,omitempty"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	TzID      string  `json:"tz_id,omitempty"`
+	Localtime string  `json:"localtime,omitempty"`
+}
+
+// CurrentWeatherResult resultado estructurado de get_current_weather.
+// Las mediciones están en el sistema de unidades del resultado
+type CurrentWeatherResult struct {
+	Location      PlaceData          `json:"location"`
+	Units         units.System       `json:"units"`
+	LastUpdated   string             `json:"last_updated"`
+	Condition     string             `json:"condition"`
+	ConditionCode int                `json:"condition_code"`
+	IsDay         bool               `json:"is_day"`
+	Temperature   float64            `json:"temperature"`
+	FeelsLike     float64            `json:"feels_like"`
+	Wind          float64            `json:"wind"`
+	WindDir       string             `json:"wind_dir"`
+	Gust          float64            `json:"gust"`
+	Humidity      int                `json:"humidity"`
+	Cloud         int                `json:"cloud"`
+	Pressure      float64            `json:"pressure"`
+	Precipitation float64            `json:"precipitation"`
+	Visibility    float64            `json:"visibility"`
+	UV            float64            `json:"uv"`
+	AirQuality    *models.AirQuality `json:"air_quality,omitempty"`
+}
+
 // GetCurrentWeather obtiene el clima actual para una ubicación
-func GetCurrentWeather(cfg *config.Config, params map[string]interface{}) (interface{}, error) {
-	location, ok := params["location"].(string)
-	if !ok || location == "" {
-		return nil, fmt.Errorf("parámetro 'location' es requerido")
+func GetCurrentWeather(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error) {
+	tr, err := langParam(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := requiredString(tr, params, "location")
+	if err != nil {
+		return nil, err
+	}
+
+	system, err := unitsParam(cfg, tr, params)
+	if err != nil {
+		return nil, err
 	}
 
 	// Parámetro opcional para calidad del aire
@@ -25,88 +73,116 @@ func GetCurrentWeather(cfg *config.Config, params map[string]interface{}) (inter
 		}
 	}
 
-	// Construir URL
-	baseURL := fmt.Sprintf("%s/current.json", cfg.BaseURL)
-	params_url := url.Values{}
-	params_url.Add("key", cfg.WeatherAPIKey)
-	params_url.Add("q", location)
-	params_url.Add("aqi", aqi)
-
-	fullURL := fmt.Sprintf("%s?%s", baseURL, params_url.Encode())
+	query := url.Values{}
+	query.Add("q", location)
+	query.Add("aqi", aqi)
 
-	// Hacer request
-	resp, err := http.Get(fullURL)
-	if err != nil {
-		return nil, fmt.Errorf("error conectando con WeatherAPI: %v", err)
+	var weatherResp models.CurrentWeatherResponse
+	if err := getWeatherAPI(ctx, cfg, tr, "current.json", query, &weatherResp); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error de WeatherAPI (código %d): verificar ubicación y API key", resp.StatusCode)
+	current := weatherResp.Current
+	result := &CurrentWeatherResult{
+		Location:      placeData(ctx, weatherResp.Location),
+		Units:         system,
+		LastUpdated:   current.LastUpdated,
+		Condition:     current.Condition.Text,
+		ConditionCode: current.Condition.Code,
+		IsDay:         current.IsDay == 1,
+		Temperature:   roundTo(system.Temp(current.TempC), 1),
+		FeelsLike:     roundTo(system.Temp(current.FeelslikeC), 1),
+		Wind:          roundTo(system.SpeedValue(current.WindKph), 1),
+		WindDir:       current.WindDir,
+		Gust:          roundTo(system.SpeedValue(current.GustKph), 1),
+		Humidity:      current.Humidity,
+		Cloud:         current.Cloud,
+		Pressure:      roundTo(system.PressureValue(current.PressureMb), 2),
+		Precipitation: roundTo(system.PrecipValue(current.PrecipMm), 2),
+		Visibility:    roundTo(system.DistanceValue(current.VisKm), 1),
+		UV:            current.UV,
+		AirQuality:    current.AirQuality,
 	}
 
-	// Decodificar respuesta
-	var weatherResp models.CurrentWeatherResponse
-	if err := json.NewDecoder(resp.Body).Decode(&weatherResp); err != nil {
-		return nil, fmt.Errorf("error decodificando respuesta: %v", err)
+	toolResult := &ToolResult{
+		Structured: result,
+		Document:   currentWeatherDocument(tr, result, current),
+	}
+	if boolParam(params, "icons", false) {
+		toolResult.attachConditionIcon(cfg, current.Condition, result.IsDay, current.Condition.Text)
 	}
 
-	// Formatear respuesta legible
-	result := fmt.Sprintf(`🌤️ CLIMA ACTUAL
-📍 Ubicación: %s, %s, %s
-🌡️  Temperatura: %.1f°C (%.1f°F)
-🌦️  Condición: %s
-💨 Viento: %.1f km/h %s
-💧 Humedad: %d%%
-☁️  Nubosidad: %d%%
-👁️  Visibilidad: %.1f km
-🌡️  Sensación térmica: %.1f°C
-📊 Presión: %.1f mb
-🌧️  Precipitación: %.1f mm
-☀️  Índice UV: %.1f
-
-⏰ Última actualización: %s`,
-		weatherResp.Location.Name,
-		weatherResp.Location.Region,
-		weatherResp.Location.Country,
-		weatherResp.Current.TempC,
-		weatherResp.Current.TempF,
-		weatherResp.Current.Condition.Text,
-		weatherResp.Current.WindKph,
-		weatherResp.Current.WindDir,
-		weatherResp.Current.Humidity,
-		weatherResp.Current.Cloud,
-		weatherResp.Current.VisKm,
-		weatherResp.Current.FeelslikeC,
-		weatherResp.Current.PressureMb,
-		weatherResp.Current.PrecipMm,
-		weatherResp.Current.UV,
-		weatherResp.Current.LastUpdated,
-	)
+	return toolResult, nil
+}
+
+// currentWeatherDocument arma la presentación del clima actual
+func currentWeatherDocument(tr i18n.Translator, r *CurrentWeatherResult, c models.CurrentInfo) *render.Document {
+	u := r.Units
+	doc := &render.Document{
+		Icon:  "🌤️",
+		Title: tr.T("current.title"),
+		Summary: tr.T("current.summary", placeLabel(r.Location), u.FormatTemp(c.TempC), r.Condition,
+			u.FormatSpeed(c.WindKph), r.WindDir, r.Humidity),
+		Fields: []render.Field{
+			{Icon: "📍", Label: tr.T("label.location"), Value: placeLabel(r.Location)},
+			{Icon: "🌡️", Label: tr.T("label.temperature"), Value: u.FormatTemp(c.TempC)},
+			{Icon: "🌦️", Label: tr.T("label.condition"), Value: r.Condition},
+			{Icon: "💨", Label: tr.T("label.wind"), Value: u.FormatSpeed(c.WindKph) + " " + r.WindDir},
+			{Icon: "💧", Label: tr.T("label.humidity"), Value: fmt.Sprintf("%d%%", r.Humidity)},
+			{Icon: "☁️", Label: tr.T("label.cloud"), Value: fmt.Sprintf("%d%%", r.Cloud)},
+			{Icon: "👁️", Label: tr.T("label.visibility"), Value: u.FormatDistance(c.VisKm)},
+			{Icon: "🌡️", Label: tr.T("label.feels_like"), Value: u.FormatTemp(c.FeelslikeC)},
+			{Icon: "📊", Label: tr.T("label.pressure"), Value: u.FormatPressure(c.PressureMb)},
+			{Icon: "🌧️", Label: tr.T("label.precipitation"), Value: u.FormatPrecip(c.PrecipMm)},
+			{Icon: "☀️", Label: tr.T("label.uv"), Value: fmt.Sprintf("%.1f", r.UV)},
+		},
+		Notes: []render.Field{
+			{Icon: "⏰", Label: tr.T("label.last_updated"), Value: r.LastUpdated},
+		},
+	}
 
 	// Agregar calidad del aire si está disponible
-	if weatherResp.Current.AirQuality != nil {
-		result += fmt.Sprintf(`
-
-🌬️  CALIDAD DEL AIRE
-• CO: %.1f µg/m³
-• NO2: %.1f µg/m³  
-• O3: %.1f µg/m³
-• SO2: %.1f µg/m³
-• PM2.5: %.1f µg/m³
-• PM10: %.1f µg/m³
-• Índice EPA: %d
-• Índice DEFRA: %d`,
-			weatherResp.Current.AirQuality.CO,
-			weatherResp.Current.AirQuality.NO2,
-			weatherResp.Current.AirQuality.O3,
-			weatherResp.Current.AirQuality.SO2,
-			weatherResp.Current.AirQuality.PM25,
-			weatherResp.Current.AirQuality.PM10,
-			weatherResp.Current.AirQuality.USEPAIndex,
-			weatherResp.Current.AirQuality.GBDefraIndex,
-		)
+	if aq := r.AirQuality; aq != nil {
+		doc.Sections = append(doc.Sections, render.Section{
+			Icon:  "🌬️",
+			Title: tr.T("current.air_quality"),
+			Fields: []render.Field{
+				{Label: "CO", Value: fmt.Sprintf("%.1f µg/m³", aq.CO)},
+				{Label: "NO2", Value: fmt.Sprintf("%.1f µg/m³", aq.NO2)},
+				{Label: "O3", Value: fmt.Sprintf("%.1f µg/m³", aq.O3)},
+				{Label: "SO2", Value: fmt.Sprintf("%.1f µg/m³", aq.SO2)},
+				{Label: "PM2.5", Value: fmt.Sprintf("%.1f µg/m³", aq.PM25)},
+				{Label: "PM10", Value: fmt.Sprintf("%.1f µg/m³", aq.PM10)},
+				{Label: tr.T("label.epa_index"), Value: fmt.Sprintf("%d", aq.USEPAIndex)},
+				{Label: tr.T("label.defra_index"), Value: fmt.Sprintf("%d", aq.GBDefraIndex)},
+			},
+		})
 	}
 
-	return result, nil
+	return doc
+}
+
+// placeData convierte la ubicación de WeatherAPI
+func placeData(ctx context.Context, l models.LocationInfo) PlaceData {
+	return PlaceData{
+		LocationIdentity: locationIdentity(ctx),
+		Name:             l.Name,
+		Region:           l.Region,
+		Country:          l.Country,
+		Lat:              l.Lat,
+		Lon:              l.Lon,
+		TzID:             l.TzID,
+		Localtime:        l.Localtime,
+	}
+}
+
+// placeLabel nombre legible "Ciudad, Región, País" omitiendo las partes vacías
+func placeLabel(p PlaceData) string {
+	label := p.Name
+	for _, part := range []string{p.Region, p.Country} {
+		if part != "" {
+			label += ", " + part
+		}
+	}
+	return label
 }
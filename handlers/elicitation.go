@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"unicode"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/gazetteer"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+	"weather-mcp-server/render"
+)
+
+// elicitAccept acción de elicitation/create cuando el usuario respondió; las otras
+// ("decline", "cancel") significan que no eligió
+const elicitAccept = "accept"
+
+// ElicitRequest parámetros de elicitation/create: el mensaje para el usuario y el
+// esquema JSON del formulario
+type ElicitRequest struct {
+	Message         string                 `json:"message"`
+	RequestedSchema map[string]interface{} `json:"requestedSchema"`
+}
+
+// ElicitResult respuesta del cliente a elicitation/create; Content solo viene con
+// Action "accept"
+type ElicitResult struct {
+	Action  string                 `json:"action"`
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// Elicitor envía elicitation/create al cliente y espera la respuesta del usuario
+type Elicitor func(ctx context.Context, request ElicitRequest) (*ElicitResult, error)
+
+// elicitorKey clave del Elicitor en el contexto
+type elicitorKey struct{}
+
+// WithElicitor devuelve un contexto con el que las herramientas pueden preguntar al
+// usuario; solo se usa si el cliente anunció la capacidad elicitation
+func WithElicitor(ctx context.Context, elicitor Elicitor) context.Context {
+	return context.WithValue(ctx, elicitorKey{}, elicitor)
+}
+
+// elicitorFrom devuelve el Elicitor del contexto; nil si el cliente no soporta elicitation
+func elicitorFrom(ctx context.Context) Elicitor {
+	elicitor, _ := ctx.Value(elicitorKey{}).(Elicitor)
+	return elicitor
+}
+
+// LocationCandidate ubicación que coincide con una consulta ambigua
+type LocationCandidate struct {
+	models.LocationSearchResult
+	// Location valor de 'location' que identifica esta ubicación (ej: "id:2801268", o
+	// las coordenadas si salió del gazetteer)
+	Location string `json:"location"`
+	Label    string `json:"label"`
+}
+
+// AmbiguousLocationResult resultado (con isError) de una herramienta cuya 'location'
+// coincide con varias ubicaciones y el usuario no quiso elegir ninguna
+type AmbiguousLocationResult struct {
+	Query      string              `json:"query"`
+	Candidates []LocationCandidate `json:"candidates"`
+	Reason     string              `json:"reason"`
+}
+
+// disambiguateLocation aclara un nombre con varias coincidencias (ej: "Santiago",
+// "Valencia"). Si el cliente soporta elicitation pregunta al usuario cuál quiso decir
+// y devuelve la elegida; si el usuario rechaza la pregunta devuelve el resultado con
+// la lista de candidatas para el modelo. Sin elicitation (ej: por HTTP), o si la
+// pregunta falla, devuelve la primera coincidencia y las demás como alternativas
+func disambiguateLocation(ctx context.Context, tr i18n.Translator, location string, candidates []LocationCandidate) (*LocationCandidate, []LocationCandidate, *ToolResult, error) {
+	elicitor := elicitorFrom(ctx)
+	if elicitor == nil {
+		return &candidates[0], candidates[1:], nil, nil
+	}
+
+	choice, err := elicitLocation(ctx, elicitor, tr, location, candidates)
+	switch {
+	case choice != nil:
+		return choice, nil, nil, nil
+	case ctx.Err() != nil:
+		return nil, nil, nil, ctx.Err()
+	case err != nil:
+		logEvent(logWarning, "elicitation", "log.elicitation_failed", map[string]interface{}{
+			"location": location,
+			"error":    err.Error(),
+		})
+		return &candidates[0], candidates[1:], nil, nil
+	}
+
+	result := &AmbiguousLocationResult{Query: location, Candidates: candidates, Reason: tr.T("ambiguous.declined")}
+	return nil, nil, &ToolResult{
+		Structured: result,
+		Document:   ambiguousLocationDocument(tr, result),
+		Template:   "ambiguous_location",
+		IsError:    true,
+	}, nil
+}
+
+// locationCandidates ubicaciones de search.json (o del gazetteer) que se llaman como
+// la consulta; si la consulta trae más partes (ej: "Valencia, Spain") solo las que las
+// contienen. Las coordenadas, IPs e identificadores (id:, iata:, metar:, auto:ip) no
+// se buscan. La lista se guarda en la caché de respuestas: las mismas candidatas
+// sirven para cualquier sesión, solo la elección del usuario es de cada una
+func locationCandidates(ctx context.Context, cfg *config.Config, tr i18n.Translator, location string) ([]LocationCandidate, error) {
+	if !isPlaceName(location) {
+		return nil, nil
+	}
+	cache, _ := upstream(cfg)
+	cacheKey := fmt.Sprintf("candidates|%s|%s", tr.Lang, strings.ToLower(location))
+	if body, ok := cache.get(cacheKey); ok {
+		var candidates []LocationCandidate
+		if err := json.Unmarshal(body, &candidates); err == nil {
+			return candidates, nil
+		}
+	}
+
+	results, _, err := searchLocations(ctx, cfg, tr, location, gazetteer.Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(location, ",")
+	name := gazetteer.Fold(parts[0])
+	var candidates []LocationCandidate
+	for _, loc := range results {
+		if gazetteer.Fold(loc.Name) != name {
+			continue
+		}
+		label := searchResultLabel(loc)
+		if !containsAll(label, parts[1:]) {
+			continue
+		}
+		// Los lugares del gazetteer no tienen ID de WeatherAPI: se identifican por coordenadas
+		ref := formatLatLon(loc.Lat, loc.Lon)
+		if loc.ID > 0 {
+			ref = fmt.Sprintf("id:%d", loc.ID)
+		}
+		candidates = append(candidates, LocationCandidate{
+			LocationSearchResult: loc,
+			Location:             ref,
+			Label:                label,
+		})
+	}
+	if body, err := json.Marshal(candidates); err == nil {
+		cache.put(cacheKey, body)
+	}
+	return candidates, nil
+}
+
+// isPlaceName si la ubicación es un nombre a buscar y no coordenadas, una IP o un
+// identificador de WeatherAPI
+func isPlaceName(location string) bool {
+	lower := strings.ToLower(strings.TrimSpace(location))
+	for _, prefix := range []string{"id:", "iata:", "metar:", "auto:ip"} {
+		if strings.HasPrefix(lower, prefix) {
+			return false
+		}
+	}
+	if _, _, ok := parseLatLon(lower); ok || net.ParseIP(lower) != nil {
+		return false
+	}
+	return strings.IndexFunc(lower, unicode.IsLetter) >= 0
+}
+
+// containsAll si text contiene cada una de las partes, sin distinguir mayúsculas
+func containsAll(text string, parts []string) bool {
+	text = strings.ToLower(text)
+	for _, part := range parts {
+		if !strings.Contains(text, strings.ToLower(strings.TrimSpace(part))) {
+			return false
+		}
+	}
+	return true
+}
+
+// elicitLocation pregunta al usuario cuál de las candidatas quiso decir; devuelve nil
+// si no eligió ninguna
+func elicitLocation(ctx context.Context, elicitor Elicitor, tr i18n.Translator, query string, candidates []LocationCandidate) (*LocationCandidate, error) {
+	values := make([]string, len(candidates))
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		values[i] = c.Location
+		names[i] = c.Label
+	}
+
+	resp, err := elicitor(ctx, ElicitRequest{
+		Message: tr.T("ambiguous.elicit_message", query, len(candidates)),
+		RequestedSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"location": map[string]interface{}{
+					"type":        "string",
+					"title":       tr.T("label.location"),
+					"description": tr.T("ambiguous.elicit_description"),
+					"enum":        values,
+					"enumNames":   names,
+				},
+			},
+			"required": []string{"location"},
+		},
+	})
+	if err != nil || resp.Action != elicitAccept {
+		return nil, err
+	}
+
+	chosen, _ := resp.Content["location"].(string)
+	for i := range candidates {
+		if candidates[i].Location == chosen {
+			return &candidates[i], nil
+		}
+	}
+	return nil, tr.Errorf("ambiguous.error_choice", chosen)
+}
+
+// ambiguousLocationDocument arma la presentación de las candidatas
+func ambiguousLocationDocument(tr i18n.Translator, r *AmbiguousLocationResult) *render.Document {
+	doc := &render.Document{
+		Icon:    "❓",
+		Title:   tr.T("ambiguous.title"),
+		Summary: tr.T("ambiguous.summary", r.Query, len(r.Candidates)),
+		Fields: []render.Field{
+			{Icon: "🔎", Label: tr.T("label.query"), Value: r.Query},
+		},
+	}
+
+	section := render.Section{Icon: "📍", Title: tr.T("ambiguous.candidates")}
+	for _, c := range r.Candidates {
+		value := c.Location
+		if coords := formatLatLon(c.Lat, c.Lon); coords != c.Location {
+			value = fmt.Sprintf("%s (%s)", c.Location, coords)
+		}
+		section.Fields = append(section.Fields, render.Field{Label: c.Label, Value: value})
+	}
+	doc.Sections = []render.Section{section}
+
+	doc.Notes = []render.Field{
+		{Icon: "ℹ️", Value: r.Reason},
+		{Icon: "💡", Value: tr.T("ambiguous.hint", r.Candidates[0].Location)},
+	}
+	return doc
+}
+
+// alternativesNote nota 🔀 de una ubicación ambigua que se resolvió sin preguntar:
+// la usada y las demás con su 'location' para repetir la consulta
+func alternativesNote(tr i18n.Translator, label string, alternatives []LocationCandidate) render.Field {
+	var others []string
+	for _, c := range alternatives {
+		others = append(others, fmt.Sprintf("%s (%s)", c.Label, c.Location))
+	}
+	return render.Field{Icon: "🔀", Value: tr.T("ambiguous.alternatives", label, strings.Join(others, "; "))}
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+	"weather-mcp-server/render"
+	"weather-mcp-server/units"
+)
+
+// Formatos de exportación
+const (
+	exportCSV    = "csv"
+	exportNDJSON = "ndjson"
+	exportICS    = "ics"
+)
+
+// exportMimeTypes tipo MIME de cada formato de exportación
+var exportMimeTypes = map[string]string{
+	exportCSV:    "text/csv",
+	exportNDJSON: "application/x-ndjson",
+	exportICS:    "text/calendar",
+}
+
+// Límites de la exportación
+const (
+	maxExportForecastDays = 10
+	maxExportHistoryDays  = 7
+	maxExportCalendarDays = 366
+)
+
+// Origen de cada fila exportada
+const (
+	sourceHistory  = "history"
+	sourceForecast = "forecast"
+)
+
+// ExportResult resultado estructurado de export_forecast; el archivo va aparte
+// como recurso embebido
+type ExportResult struct {
+	Location  PlaceData     `json:"location"`
+	Export    string        `json:"export"`
+	Interval  string        `json:"interval,omitempty"`
+	Units     *units.System `json:"units,omitempty"`
+	StartDate string        `json:"start_date"`
+	EndDate   string        `json:"end_date"`
+	Columns   []string      `json:"columns,omitempty"`
+	// Records filas del CSV/NDJSON o eventos del calendario
+	Records  int    `json:"records"`
+	URI      string `json:"uri"`
+	MimeType string `json:"mime_type"`
+	// MissingHistory fechas pasadas que WeatherAPI no devolvió (según el plan)
+	MissingHistory []string `json:"missing_history,omitempty"`
+}
+
+// exportTable datos tabulares a exportar, con un valor por columna en cada fila
+type exportTable struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// ExportForecast exporta el pronóstico diario u horario (y los días pasados que permita
+// el plan de WeatherAPI) como CSV o NDJSON, o los eventos del sol y la luna como iCalendar
+func ExportForecast(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error) {
+	tr, err := langParam(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := requiredString(tr, params, "location")
+	if err != nil {
+		return nil, err
+	}
+
+	export := stringParam(params, "export", exportCSV)
+	if _, ok := exportMimeTypes[export]; !ok {
+		return nil, tr.Errorf("export.error_format", export, strings.Join([]string{exportCSV, exportNDJSON, exportICS}, ", "))
+	}
+	if export == exportICS {
+		return exportCalendar(ctx, cfg, tr, params, location)
+	}
+
+	system, err := unitsParam(cfg, tr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := stringParam(params, "interval", chartIntervalDay)
+	if interval != chartIntervalDay && interval != chartIntervalHour {
+		return nil, tr.Errorf("export.error_interval")
+	}
+
+	days := intParam(params, "days", 3)
+	if days < 1 || days > maxExportForecastDays {
+		return nil, tr.Errorf("error.range", "days", 1, maxExportForecastDays)
+	}
+	historyDays := intParam(params, "history_days", 0)
+	if historyDays < 0 || historyDays > maxExportHistoryDays {
+		return nil, tr.Errorf("error.range", "history_days", 0, maxExportHistoryDays)
+	}
+
+	progress := newProgress(ctx, 1+historyDays)
+	forecast, err := fetchForecast(ctx, cfg, tr, location, days)
+	if err != nil {
+		return nil, err
+	}
+	if len(forecast.Forecast.Forecastday) == 0 {
+		return nil, tr.Errorf("export.error_empty")
+	}
+	progress.step(tr.T("progress.forecast", location))
+
+	// Días pasados, del más antiguo al más reciente; los que la API no
+	// devuelve se informan y no interrumpen la exportación
+	var history []models.ForecastDay
+	var missing []string
+	first, _ := time.Parse("2006-01-02", forecast.Forecast.Forecastday[0].Date)
+	for i := historyDays; i >= 1; i-- {
+		date := first.AddDate(0, 0, -i).Format("2006-01-02")
+		past, err := fetchHistory(ctx, cfg, tr, location, date)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		progress.step(tr.T("progress.history", date))
+		if err != nil || len(past.Forecast.Forecastday) == 0 {
+			missing = append(missing, date)
+			continue
+		}
+		history = append(history, past.Forecast.Forecastday[0])
+	}
+
+	table := exportDailyTable(history, forecast.Forecast.Forecastday, system)
+	if interval == chartIntervalHour {
+		table = exportHourlyTable(history, forecast.Forecast.Forecastday, system)
+	}
+
+	var data string
+	if export == exportCSV {
+		data, err = table.csv()
+	} else {
+		data, err = table.ndjson()
+	}
+	if err != nil {
+		return nil, tr.Errorf("export.error_encode", err)
+	}
+
+	startDate := forecast.Forecast.Forecastday[0].Date
+	if len(history) > 0 {
+		startDate = history[0].Date
+	}
+	forecastDays := forecast.Forecast.Forecastday
+	result := &ExportResult{
+		Location:       placeData(ctx, forecast.Location),
+		Export:         export,
+		Interval:       interval,
+		Units:          &system,
+		StartDate:      startDate,
+		EndDate:        forecastDays[len(forecastDays)-1].Date,
+		Columns:        table.Columns,
+		Records:        len(table.Rows),
+		MimeType:       exportMimeTypes[export],
+		MissingHistory: missing,
+	}
+	result.URI = resourceURI("exports", uriSlug(result.Location.Name), "forecast-"+interval+"."+export)
+
+	return &ToolResult{
+		Structured: result,
+		Document:   exportDocument(tr, result),
+		Resources:  []ToolResource{{URI: result.URI, MimeType: result.MimeType, Text: data}},
+	}, nil
+}
+
+// exportDailyTable una fila por día con los mismos campos que get_forecast
+func exportDailyTable(history, forecast []models.ForecastDay, system units.System) exportTable {
+	table := exportTable{Columns: []string{
+		"date", "source", "min_temperature", "max_temperature", "avg_temperature", "precipitation",
+		"max_wind", "humidity", "uv", "condition", "condition_code", "sunrise", "sunset", "moon_phase",
+	}}
+	add := func(days []models.ForecastDay, source string) {
+		for _, day := range days {
+			d := forecastDayData(day, system)
+			table.Rows = append(table.Rows, []interface{}{
+				d.Date, source, d.MinTemp, d.MaxTemp, d.AvgTemp, d.Precipitation,
+				d.MaxWind, d.Humidity, d.UV, d.Condition, d.ConditionCode, d.Sunrise, d.Sunset, d.MoonPhase,
+			})
+		}
+	}
+	add(history, sourceHistory)
+	add(forecast, sourceForecast)
+	return table
+}
+
+// exportHourlyTable una fila por hora
+func exportHourlyTable(history, forecast []models.ForecastDay, system units.System) exportTable {
+	table := exportTable{Columns: []string{
+		"time", "source", "temperature", "feels_like", "precipitation", "chance_of_rain", "wind",
+		"wind_degree", "wind_dir", "gust", "humidity", "cloud", "pressure", "visibility", "uv",
+		"condition", "condition_code",
+	}}
+	add := func(days []models.ForecastDay, source string) {
+		for _, day := range days {
+			for _, h := range day.Hour {
+				table.Rows = append(table.Rows, []interface{}{
+					h.Time, source,
+					roundTo(system.Temp(h.TempC), 1),
+					roundTo(system.Temp(h.FeelslikeC), 1),
+					roundTo(system.PrecipValue(h.PrecipMm), 2),
+					numberValue(h.ChanceOfRain),
+					roundTo(system.SpeedValue(h.WindKph), 1),
+					h.WindDegree, h.WindDir,
+					roundTo(system.SpeedValue(h.GustKph), 1),
+					h.Humidity, h.Cloud,
+					roundTo(system.PressureValue(h.PressureMb), 2),
+					roundTo(system.DistanceValue(h.VisKm), 1),
+					h.UV, h.Condition.Text, h.Condition.Code,
+				})
+			}
+		}
+	}
+	add(history, sourceHistory)
+	add(forecast, sourceForecast)
+	return table
+}
+
+// csv genera el CSV con una fila de encabezados
+func (t exportTable) csv() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(t.Columns); err != nil {
+		return "", err
+	}
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = exportCell(value)
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// ndjson genera un objeto JSON por línea, con las claves en el orden de las columnas
+func (t exportTable) ndjson() (string, error) {
+	var buf bytes.Buffer
+	for _, row := range t.Rows {
+		buf.WriteByte('{')
+		for i, value := range row {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(t.Columns[i])
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return "", err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(encoded)
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.String(), nil
+}
+
+// exportCell formatea un valor para el CSV sin ceros ni decimales de más
+func exportCell(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// exportDocument arma la presentación de la exportación; los datos van en el recurso
+func exportDocument(tr i18n.Translator, r *ExportResult) *render.Document {
+	doc := &render.Document{
+		Icon:    "📤",
+		Title:   tr.T("export.title"),
+		Summary: tr.T("export.summary", r.Location.Name, r.Records, strings.ToUpper(r.Export), r.StartDate, r.EndDate),
+		Fields: []render.Field{
+			{Icon: "📍", Label: tr.T("label.location"), Value: placeLabel(r.Location)},
+			{Icon: "🗂️", Label: tr.T("label.export"), Value: fmt.Sprintf("%s (%s)", strings.ToUpper(r.Export), r.MimeType)},
+			{Icon: "📅", Label: tr.T("label.range"), Value: r.StartDate + " → " + r.EndDate},
+		},
+	}
+
+	if r.Export == exportICS {
+		doc.Fields = append(doc.Fields,
+			render.Field{Icon: "🕐", Label: tr.T("label.timezone"), Value: r.Location.TzID},
+			render.Field{Icon: "🔢", Label: tr.T("label.events"), Value: strconv.Itoa(r.Records)},
+		)
+	} else {
+		doc.Fields = append(doc.Fields,
+			render.Field{Icon: "⏱️", Label: tr.T("label.interval"), Value: tr.T("export.interval_" + r.Interval)},
+			render.Field{Icon: "🔢", Label: tr.T("label.rows"), Value: strconv.Itoa(r.Records)},
+			render.Field{Icon: "📏", Label: tr.T("label.units"), Value: fmt.Sprintf("%s, %s, %s, %s, %s",
+				r.Units.Temperature, r.Units.Speed, r.Units.Precip, r.Units.Pressure, r.Units.Distance)},
+			render.Field{Icon: "🏷️", Label: tr.T("label.columns"), Value: strings.Join(r.Columns, ", ")},
+		)
+	}
+	doc.Fields = append(doc.Fields, render.Field{Icon: "📎", Label: tr.T("label.file"), Value: r.URI})
+
+	if len(r.MissingHistory) > 0 {
+		doc.Notes = append(doc.Notes, render.Field{Icon: "⚠️", Value: tr.T("export.history_missing", strings.Join(r.MissingHistory, ", "))})
+	}
+	doc.Notes = append(doc.Notes, render.Field{Icon: "💡", Value: tr.T("export.attached")})
+	return doc
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// testExportTable tabla con los casos que el CSV tiene que escapar
+var testExportTable = exportTable{
+	Columns: []string{"date", "max_temperature", "humidity", "condition"},
+	Rows: [][]interface{}{
+		{"2026-10-19", 21.5, 60, "Soleado"},
+		{"2026-10-20", 18.0, 85, `Lluvia, "moderada"`},
+		{"2026-10-21", -0.25, 100, "Nieve\nligera"},
+	},
+}
+
+func TestExportCSV(t *testing.T) {
+	got, err := testExportTable.csv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"date,max_temperature,humidity,condition",
+		"2026-10-19,21.5,60,Soleado",
+		`2026-10-20,18,85,"Lluvia, ""moderada"""`,
+		"2026-10-21,-0.25,100,\"Nieve\nligera\"",
+		"",
+	}, "\n")
+	if got != want {
+		t.Errorf("csv() =\n%s\nse esperaba\n%s", got, want)
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	got, err := testExportTable.ndjson()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != len(testExportTable.Rows) {
+		t.Fatalf("ndjson() tiene %d líneas, se esperaban %d", len(lines), len(testExportTable.Rows))
+	}
+	// Las claves van en el orden de las columnas
+	if want := `{"date":"2026-10-19","max_temperature":21.5,"humidity":60,"condition":"Soleado"}`; lines[0] != want {
+		t.Errorf("línea 1 = %s, se esperaba %s", lines[0], want)
+	}
+	for i, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Errorf("línea %d no es JSON: %v", i+1, err)
+			continue
+		}
+		if record["condition"] != testExportTable.Rows[i][3] {
+			t.Errorf("línea %d: condition = %q", i+1, record["condition"])
+		}
+	}
+}
+
+func TestExportCell(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{21.0, "21"},
+		{-0.25, "-0.25"},
+		{roundTo(18.456, 1), "18.5"},
+		{42, "42"},
+		{"N", "N"},
+		{true, "true"},
+	}
+	for _, tt := range tests {
+		if got := exportCell(tt.value); got != tt.want {
+			t.Errorf("exportCell(%v) = %q, se esperaba %q", tt.value, got, tt.want)
+		}
+	}
+}
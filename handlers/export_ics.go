@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"weather-mcp-server/astronomy"
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/render"
+)
+
+// icsLineLimit largo máximo de una línea iCalendar en octetos (RFC 5545 §3.1)
+const icsLineLimit = 75
+
+// calendarEvent evento puntual del calendario
+type calendarEvent struct {
+	Kind        string
+	Start       time.Time
+	Summary     string
+	Description string
+}
+
+// exportCalendar genera el calendario iCalendar con amaneceres, atardeceres y fases
+// lunares desde start_date (hoy por defecto) durante 'days' días. Los instantes se
+// calculan con el motor astronómico, así que funciona también sin conexión
+func exportCalendar(ctx context.Context, cfg *config.Config, tr i18n.Translator, params map[string]interface{}, location string) (interface{}, error) {
+	days := intParam(params, "days", 30)
+	if days < 1 || days > maxExportCalendarDays {
+		return nil, tr.Errorf("error.range", "days", 1, maxExportCalendarDays)
+	}
+
+	place, err := resolveAstroPlace(ctx, cfg, tr, location, time.Now().Format("2006-01-02"), stringParam(params, "timezone", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(place.loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, place.loc)
+	if value := stringParam(params, "start_date", ""); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, place.loc)
+		if err != nil {
+			return nil, tr.Errorf("error.date_format", "start_date")
+		}
+		start = t
+	}
+	end := start.AddDate(0, 0, days-1)
+
+	ephemeris, err := astronomyDays(ctx, place, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var events []calendarEvent
+	for _, day := range ephemeris {
+		for _, sun := range []struct {
+			kind  string
+			label string
+			at    *time.Time
+		}{{"sunrise", tr.T("label.sunrise"), day.Sunrise}, {"sunset", tr.T("label.sunset"), day.Sunset}} {
+			if sun.at == nil {
+				continue
+			}
+			events = append(events, calendarEvent{
+				Kind:        sun.kind,
+				Start:       *sun.at,
+				Summary:     sun.label,
+				Description: tr.T("export.ics_description", sun.label, place.Name, sun.at.In(place.loc).Format("15:04"), place.TzID),
+			})
+		}
+	}
+	for _, phase := range []string{"new_moon", "first_quarter", "full_moon", "last_quarter"} {
+		phases, err := astronomy.FindPhases(ctx, start, end.AddDate(0, 0, 1), astronomyPhaseEvents[phase], days)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range phases {
+			label := tr.T("moon." + phase)
+			events = append(events, calendarEvent{
+				Kind:        phase,
+				Start:       t,
+				Summary:     label,
+				Description: tr.T("export.ics_description", label, place.Name, t.In(place.loc).Format("15:04"), place.TzID),
+			})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+
+	result := &ExportResult{
+		Location: PlaceData{
+			LocationIdentity: place.LocationIdentity,
+			Name:             place.Name,
+			Region:           place.Region,
+			Country:          place.Country,
+			Lat:              place.Lat,
+			Lon:              place.Lon,
+			TzID:             place.TzID,
+			Localtime:        place.Localtime,
+		},
+		Export:    exportICS,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Records:   len(events),
+		MimeType:  exportMimeTypes[exportICS],
+	}
+	result.URI = resourceURI("exports", uriSlug(place.Name), "astronomy."+exportICS)
+
+	doc := exportDocument(tr, result)
+	if place.Source == "local" {
+		doc.Notes = append(doc.Notes, render.Field{Icon: "⚠️", Value: tr.T("astronomy.offline")})
+	}
+
+	return &ToolResult{
+		Structured: result,
+		Document:   doc,
+		Resources: []ToolResource{{
+			URI:      result.URI,
+			MimeType: result.MimeType,
+			Text:     icsCalendar(tr.T("export.calendar_name", place.Name), place, events),
+		}},
+	}, nil
+}
+
+// icsCalendar arma el VCALENDAR; los eventos son instantes en UTC sin duración
+func icsCalendar(name string, place *AstroPlace, events []calendarEvent) string {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(icsFold(s))
+		b.WriteString("\r\n")
+	}
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//weather-mcp-server//export_forecast//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + icsEscape(name))
+	line("X-WR-TIMEZONE:" + place.TzID)
+	for _, e := range events {
+		start := e.Start.UTC()
+		line("BEGIN:VEVENT")
+		line(fmt.Sprintf("UID:%s-%s-%.4f-%.4f@weather-mcp-server", start.Format("20060102"), e.Kind, place.Lat, place.Lon))
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + start.Format("20060102T150405Z"))
+		line("SUMMARY:" + icsEscape(e.Summary))
+		line("DESCRIPTION:" + icsEscape(e.Description))
+		line(fmt.Sprintf("GEO:%.4f;%.4f", place.Lat, place.Lon))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// icsEscape escapa un valor de texto iCalendar
+var icsEscape = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace
+
+// icsFold parte las líneas de más de 75 octetos sin cortar caracteres UTF-8;
+// las continuaciones empiezan con un espacio
+func icsFold(s string) string {
+	var b strings.Builder
+	limit := icsLineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(s)
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+)
+
+func TestICSEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Amanecer", "Amanecer"},
+		{"Madrid, España", `Madrid\, España`},
+		{"a;b", `a\;b`},
+		{`C:\tmp`, `C:\\tmp`},
+		{"línea 1\nlínea 2", `línea 1\nlínea 2`},
+	}
+	for _, tt := range tests {
+		if got := icsEscape(tt.in); got != tt.want {
+			t.Errorf("icsEscape(%q) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestICSFold(t *testing.T) {
+	tests := []string{
+		"SUMMARY:Amanecer",
+		"DESCRIPTION:" + strings.Repeat("a", 200),
+		"DESCRIPTION:" + strings.Repeat("ñ", 100),
+		"DESCRIPTION:" + strings.Repeat("🌕", 40),
+		strings.Repeat("x", icsLineLimit),
+	}
+	for _, in := range tests {
+		got := icsFold(in)
+		lines := strings.Split(got, "\r\n")
+		for i, line := range lines {
+			if len(line) > icsLineLimit {
+				t.Errorf("icsFold: la línea %d tiene %d octetos", i, len(line))
+			}
+			if !utf8.ValidString(line) {
+				t.Errorf("icsFold: la línea %d corta un carácter UTF-8: %q", i, line)
+			}
+			if i > 0 && !strings.HasPrefix(line, " ") {
+				t.Errorf("icsFold: la continuación %d no empieza con un espacio", i)
+			}
+		}
+		if unfolded := strings.ReplaceAll(got, "\r\n ", ""); unfolded != in {
+			t.Errorf("icsFold: al desplegar se obtiene %q, se esperaba %q", unfolded, in)
+		}
+	}
+}
+
+func TestExportCalendarCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := &config.Config{BaseURL: "http://127.0.0.1:1", RateLimit: 60}
+	params := map[string]interface{}{"days": float64(maxExportCalendarDays)}
+	// Con coordenadas la ubicación se resuelve sin WeatherAPI; el calendario se
+	// abandona en el primer día
+	if _, err := exportCalendar(ctx, cfg, i18n.For("es"), params, "40.4168,-3.7038"); err != context.Canceled {
+		t.Errorf("err = %v, se esperaba context.Canceled", err)
+	}
+}
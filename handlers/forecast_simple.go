@@ -1,140 +1,170 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"net/url"
-	"strconv"
+	"strings"
 
 	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+	"weather-mcp-server/render"
+	"weather-mcp-server/units"
 )
 
-// SimpleForecastResponse estructura simplificada sin datos astronómicos detallados
-type SimpleForecastResponse struct {
-	Location struct {
-		Name      string  `json:"name"`
-		Region    string  `json:"region"`
-		Country   string  `json:"country"`
-		Lat       float64 `json:"lat"`
-		Lon       float64 `json:"lon"`
-		Localtime string  `json:"localtime"`
-	} `json:"location"`
-	Forecast struct {
-		Forecastday []struct {
-			Date string `json:"date"`
-			Day  struct {
-				MaxtempC      float64 `json:"maxtemp_c"`
-				MintempC      float64 `json:"mintemp_c"`
-				AvgtempC      float64 `json:"avgtemp_c"`
-				MaxwindKph    float64 `json:"maxwind_kph"`
-				TotalprecipMm float64 `json:"totalprecip_mm"`
-				Avghumidity   float64 `json:"avghumidity"`
-				UV            float64 `json:"uv"`
-				Condition     struct {
-					Text string `json:"text"`
-					Icon string `json:"icon"`
-					Code int    `json:"code"`
-				} `json:"condition"`
-			} `json:"day"`
-			Astro struct {
-				Sunrise   string `json:"sunrise"`
-				Sunset    string `json:"sunset"`
-				Moonrise  string `json:"moonrise"`
-				Moonset   string `json:"moonset"`
-				MoonPhase string `json:"moon_phase"`
-			} `json:"astro"`
-		} `json:"forecastday"`
-	} `json:"forecast"`
+// ForecastDayData un día del pronóstico en el sistema de unidades del resultado
+type ForecastDayData struct {
+	Date          string  `json:"date"`
+	MinTemp       float64 `json:"min_temperature"`
+	MaxTemp       float64 `json:"max_temperature"`
+	AvgTemp       float64 `json:"avg_temperature"`
+	Condition     string  `json:"condition"`
+	ConditionCode int     `json:"condition_code"`
+	Humidity      float64 `json:"humidity"`
+	MaxWind       float64 `json:"max_wind"`
+	Precipitation float64 `json:"precipitation"`
+	UV            float64 `json:"uv"`
+	Sunrise       string  `json:"sunrise"`
+	Sunset        string  `json:"sunset"`
+	MoonPhase     string  `json:"moon_phase"`
+}
+
+// ForecastResult resultado estructurado de get_forecast
+type ForecastResult struct {
+	Location PlaceData         `json:"location"`
+	Units    units.System      `json:"units"`
+	Days     []ForecastDayData `json:"days"`
 }
 
 // GetForecastSimple obtiene el pronóstico del tiempo de forma simplificada
-func GetForecastSimple(cfg *config.Config, params map[string]interface{}) (interface{}, error) {
-	location, ok := params["location"].(string)
-	if !ok || location == "" {
-		return nil, fmt.Errorf("parámetro 'location' es requerido")
+func GetForecastSimple(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error) {
+	tr, err := langParam(cfg, params)
+	if err != nil {
+		return nil, err
 	}
 
-	// Parámetros opcionales
-	days := 3
-	if daysParam, exists := params["days"]; exists {
-		if daysFloat, ok := daysParam.(float64); ok {
-			days = int(daysFloat)
-		}
+	location, err := requiredString(tr, params, "location")
+	if err != nil {
+		return nil, err
+	}
+
+	system, err := unitsParam(cfg, tr, params)
+	if err != nil {
+		return nil, err
 	}
+
+	// Parámetros opcionales
+	days := intParam(params, "days", 3)
 	if days < 1 || days > 10 {
 		days = 3
 	}
 
-	// Construir URL
-	baseURL := fmt.Sprintf("%s/forecast.json", cfg.BaseURL)
-	params_url := url.Values{}
-	params_url.Add("key", cfg.WeatherAPIKey)
-	params_url.Add("q", location)
-	params_url.Add("days", strconv.Itoa(days))
-	params_url.Add("aqi", "no")
-	params_url.Add("alerts", "no")
-
-	fullURL := fmt.Sprintf("%s?%s", baseURL, params_url.Encode())
+	chartFormat, err := chartParam(tr, params)
+	if err != nil {
+		return nil, err
+	}
+	interval := stringParam(params, "chart_interval", chartIntervalDay)
+	if interval != chartIntervalDay && interval != chartIntervalHour {
+		return nil, tr.Errorf("error.chart_interval")
+	}
 
-	// Hacer request
-	resp, err := http.Get(fullURL)
+	forecast, err := fetchForecast(ctx, cfg, tr, location, days)
 	if err != nil {
-		return nil, fmt.Errorf("error conectando con WeatherAPI: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error de WeatherAPI (código %d): verificar ubicación y API key", resp.StatusCode)
+	result := &ForecastResult{
+		Location: placeData(ctx, forecast.Location),
+		Units:    system,
+	}
+	for _, day := range forecast.Forecast.Forecastday {
+		result.Days = append(result.Days, forecastDayData(day, system))
 	}
 
-	// Decodificar respuesta
-	var forecastResp SimpleForecastResponse
-	if err := json.NewDecoder(resp.Body).Decode(&forecastResp); err != nil {
-		return nil, fmt.Errorf("error decodificando respuesta: %v", err)
+	toolResult := &ToolResult{
+		Structured: result,
+		Document:   forecastDocument(tr, result, forecast),
 	}
 
-	// Formatear respuesta legible
-	result := fmt.Sprintf(`🌦️ PRONÓSTICO DEL TIEMPO (%d días)
-📍 Ubicación: %s, %s, %s
-🕐 Hora local: %s
-
-`,
-		days,
-		forecastResp.Location.Name,
-		forecastResp.Location.Region,
-		forecastResp.Location.Country,
-		forecastResp.Location.Localtime,
-	)
-
-	// Agregar cada día del pronóstico
-	for i, day := range forecastResp.Forecast.Forecastday {
-		result += fmt.Sprintf(`📅 DÍA %d - %s
-🌡️  Temperatura: %.1f°C - %.1f°C (promedio: %.1f°C)
-🌦️  Condición: %s
-💧 Humedad promedio: %.0f%%
-💨 Viento máximo: %.1f km/h
-🌧️  Precipitación total: %.1f mm
-☀️  Índice UV: %.1f
-🌅 Amanecer: %s | 🌇 Atardecer: %s
-🌙 Fase lunar: %s
-
-`,
-			i+1,
-			day.Date,
-			day.Day.MintempC,
-			day.Day.MaxtempC,
-			day.Day.AvgtempC,
-			day.Day.Condition.Text,
-			day.Day.Avghumidity,
-			day.Day.MaxwindKph,
-			day.Day.TotalprecipMm,
-			day.Day.UV,
-			day.Astro.Sunrise,
-			day.Astro.Sunset,
-			day.Astro.MoonPhase,
-		)
+	// Íconos opcionales de la condición de cada día
+	if boolParam(params, "icons", false) {
+		for _, day := range forecast.Forecast.Forecastday {
+			toolResult.attachConditionIcon(cfg, day.Day.Condition, true, day.Date+": "+day.Day.Condition.Text)
+		}
+	}
+
+	// Gráfico opcional: un punto por día o por hora
+	if chartFormat != "" {
+		series := dailyChart(tr, result.Location.Name, forecast.Forecast.Forecastday, system)
+		if interval == chartIntervalHour {
+			var hours []models.HourInfo
+			for _, day := range forecast.Forecast.Forecastday {
+				hours = append(hours, day.Hour...)
+			}
+			series = hourlyChart(tr, result.Location.Name, hours, system)
+		}
+		if err := toolResult.attachChart(chartFormat, series); err != nil {
+			return nil, err
+		}
+	}
+
+	return toolResult, nil
+}
+
+// forecastDayData convierte un día de WeatherAPI al sistema de unidades
+func forecastDayData(day models.ForecastDay, system units.System) ForecastDayData {
+	return ForecastDayData{
+		Date:          day.Date,
+		MinTemp:       roundTo(system.Temp(day.Day.MintempC), 1),
+		MaxTemp:       roundTo(system.Temp(day.Day.MaxtempC), 1),
+		AvgTemp:       roundTo(system.Temp(day.Day.AvgtempC), 1),
+		Condition:     day.Day.Condition.Text,
+		ConditionCode: day.Day.Condition.Code,
+		Humidity:      day.Day.Avghumidity,
+		MaxWind:       roundTo(system.SpeedValue(day.Day.MaxwindKph), 1),
+		Precipitation: roundTo(system.PrecipValue(day.Day.TotalprecipMm), 2),
+		UV:            day.Day.UV,
+		Sunrise:       day.Astro.Sunrise,
+		Sunset:        day.Astro.Sunset,
+		MoonPhase:     day.Astro.MoonPhase,
+	}
+}
+
+// forecastDocument arma la presentación del pronóstico, un bloque por día
+func forecastDocument(tr i18n.Translator, r *ForecastResult, forecast *models.ForecastResponse) *render.Document {
+	u := r.Units
+	doc := &render.Document{
+		Icon:  "🌦️",
+		Title: tr.T("forecast.title", len(r.Days)),
+		Fields: []render.Field{
+			{Icon: "📍", Label: tr.T("label.location"), Value: placeLabel(r.Location)},
+			{Icon: "🕐", Label: tr.T("label.local_time"), Value: r.Location.Localtime},
+		},
+	}
+
+	var summary []string
+	for i, day := range forecast.Forecast.Forecastday {
+		d := r.Days[i]
+		summary = append(summary, fmt.Sprintf("%s %s/%s %s", d.Date, u.FormatTemp(day.Day.MintempC), u.FormatTemp(day.Day.MaxtempC), d.Condition))
+
+		doc.Sections = append(doc.Sections, render.Section{
+			Icon:  "📅",
+			Title: tr.T("forecast.day_title", i+1, d.Date),
+			Fields: []render.Field{
+				{Icon: "🌡️", Label: tr.T("label.temperature"), Value: tr.T("forecast.temp_range",
+					u.FormatTemp(day.Day.MintempC), u.FormatTemp(day.Day.MaxtempC), u.FormatTemp(day.Day.AvgtempC))},
+				{Icon: "🌦️", Label: tr.T("label.condition"), Value: d.Condition},
+				{Icon: "💧", Label: tr.T("label.avg_humidity"), Value: fmt.Sprintf("%.0f%%", d.Humidity)},
+				{Icon: "💨", Label: tr.T("label.max_wind"), Value: u.FormatSpeed(day.Day.MaxwindKph)},
+				{Icon: "🌧️", Label: tr.T("label.total_precipitation"), Value: u.FormatPrecip(day.Day.TotalprecipMm)},
+				{Icon: "☀️", Label: tr.T("label.uv"), Value: fmt.Sprintf("%.1f", d.UV)},
+				{Icon: "🌅", Label: tr.T("label.sunrise"), Value: d.Sunrise},
+				{Icon: "🌇", Label: tr.T("label.sunset"), Value: d.Sunset},
+				{Icon: "🌙", Label: tr.T("label.moon_phase"), Value: d.MoonPhase},
+			},
+		})
 	}
+	doc.Summary = r.Location.Name + ": " + strings.Join(summary, "; ")
 
-	return result, nil
+	return doc
 }
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/gazetteer"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+)
+
+// gazetteerSearchLimit resultados que devuelve una búsqueda en el gazetteer, como
+// search.json de WeatherAPI
+const gazetteerSearchLimit = 10
+
+// gazetteerNearbyKm distancia máxima para nombrar unas coordenadas con el lugar más
+// cercano del gazetteer
+const gazetteerNearbyKm = 50.0
+
+// Origen de los resultados de search_locations
+const (
+	searchSourceAPI       = "weatherapi"
+	searchSourceGazetteer = "gazetteer"
+)
+
+// searchLocations busca ubicaciones por nombre, o la más cercana si la consulta son
+// coordenadas, y devuelve también el origen de los resultados. Con el gazetteer en
+// modo "first" se busca primero en él y solo se consulta WeatherAPI si no encuentra
+// nada; en modo "fallback" se usa cuando WeatherAPI no responde. Los resultados de
+// WeatherAPI también se filtran por país y región
+func searchLocations(ctx context.Context, cfg *config.Config, tr i18n.Translator, query string, filter gazetteer.Filter) ([]models.LocationSearchResult, string, error) {
+	first := cfg.Gazetteer != nil && cfg.GazetteerMode == config.GazetteerFirst
+	if first {
+		if results := gazetteerSearch(cfg.Gazetteer, query, filter); len(results) > 0 {
+			return results, searchSourceGazetteer, nil
+		}
+	}
+
+	results, err := fetchSearch(ctx, cfg, tr, query)
+	if err != nil {
+		if cfg.Gazetteer == nil || first || ctx.Err() != nil {
+			return nil, "", err
+		}
+		logEvent(logNotice, "gazetteer", "log.gazetteer_fallback", map[string]interface{}{
+			"query": query,
+			"error": err.Error(),
+		})
+		return gazetteerSearch(cfg.Gazetteer, query, filter), searchSourceGazetteer, nil
+	}
+
+	if filter == (gazetteer.Filter{}) {
+		return results, searchSourceAPI, nil
+	}
+	var filtered []models.LocationSearchResult
+	for _, r := range results {
+		if cfg.Gazetteer.MatchesFilter(filter, r.Country, r.Region) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, searchSourceAPI, nil
+}
+
+// gazetteerSearch busca en el gazetteer: por nombre, o el lugar más cercano si la
+// consulta son coordenadas. Los lugares no tienen ID de WeatherAPI
+func gazetteerSearch(g *gazetteer.Gazetteer, query string, filter gazetteer.Filter) []models.LocationSearchResult {
+	if lat, lon, ok := parseLatLon(query); ok {
+		place, _, ok := g.Reverse(lat, lon, filter)
+		if !ok {
+			return nil
+		}
+		return []models.LocationSearchResult{gazetteerResult(place)}
+	}
+
+	var results []models.LocationSearchResult
+	for _, match := range g.Search(query, filter, gazetteerSearchLimit) {
+		results = append(results, gazetteerResult(match.Place))
+	}
+	return results
+}
+
+// gazetteerResult lugar del gazetteer como resultado de búsqueda
+func gazetteerResult(place gazetteer.Place) models.LocationSearchResult {
+	return models.LocationSearchResult{
+		Name:    place.Name,
+		Region:  place.Region,
+		Country: place.Country,
+		Lat:     place.Lat,
+		Lon:     place.Lon,
+	}
+}
+
+// gazetteerNearby lugar del gazetteer a menos de gazetteerNearbyKm de las
+// coordenadas; false si no hay gazetteer o ningún lugar está tan cerca
+func gazetteerNearby(g *gazetteer.Gazetteer, lat, lon float64) (gazetteer.Place, bool) {
+	place, distance, ok := g.Reverse(lat, lon, gazetteer.Filter{})
+	return place, ok && distance <= gazetteerNearbyKm
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+
+	"weather-mcp-server/i18n"
+)
+
+// geoJSONMimeType tipo MIME de GeoJSON (RFC 7946)
+const geoJSONMimeType = "application/geo+json"
+
+// GeoJSONFeatureCollection colección de features GeoJSON
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature feature con su geometría y propiedades
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONGeometry geometría Point ([lon, lat]) o LineString ([[lon, lat], ...])
+type GeoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// newFeatureCollection crea una colección vacía
+func newFeatureCollection() *GeoJSONFeatureCollection {
+	return &GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []GeoJSONFeature{}}
+}
+
+// addPoint agrega un punto; GeoJSON ordena las coordenadas como longitud, latitud
+func (c *GeoJSONFeatureCollection) addPoint(lat, lon float64, properties map[string]interface{}) {
+	c.Features = append(c.Features, GeoJSONFeature{
+		Type:       "Feature",
+		Geometry:   GeoJSONGeometry{Type: "Point", Coordinates: []float64{lon, lat}},
+		Properties: properties,
+	})
+}
+
+// addLine agrega una línea entre dos puntos
+func (c *GeoJSONFeatureCollection) addLine(fromLat, fromLon, toLat, toLon float64, properties map[string]interface{}) {
+	c.Features = append(c.Features, GeoJSONFeature{
+		Type:       "Feature",
+		Geometry:   GeoJSONGeometry{Type: "LineString", Coordinates: [][]float64{{fromLon, fromLat}, {toLon, toLat}}},
+		Properties: properties,
+	})
+}
+
+// attachGeoJSON agrega la colección como recurso embebido application/geo+json
+func (r *ToolResult) attachGeoJSON(tr i18n.Translator, kind string, names []string, c *GeoJSONFeatureCollection) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return tr.Errorf("export.error_encode", err)
+	}
+	r.Resources = append(r.Resources, ToolResource{
+		URI:      resourceURI("geojson", uriSlug(strings.Join(names, " ")), kind+".geojson"),
+		MimeType: geoJSONMimeType,
+		Text:     string(data),
+	})
+	return nil
+}
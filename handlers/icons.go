@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"mime"
+	"path"
+	"strings"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/models"
+)
+
+// attachConditionIcon agrega el ícono de la condición: como imagen si el set local
+// tiene el código, si no como enlace al CDN de WeatherAPI
+func (r *ToolResult) attachConditionIcon(cfg *config.Config, condition models.Condition, isDay bool, name string) {
+	if icon, ok := cfg.Icons.Lookup(condition.Code, isDay); ok {
+		r.Images = append(r.Images, ToolImage{MimeType: icon.MimeType, Data: icon.Data})
+		return
+	}
+
+	uri := conditionIconURL(condition.Icon)
+	if uri == "" {
+		return
+	}
+	r.Links = append(r.Links, ToolLink{
+		URI:         uri,
+		Name:        name,
+		MimeType:    mime.TypeByExtension(path.Ext(uri)),
+		Description: condition.Text,
+	})
+}
+
+// conditionIconURL normaliza la URL del ícono de WeatherAPI, que llega sin
+// esquema ("//cdn.weatherapi.com/..."), a https
+func conditionIconURL(icon string) string {
+	switch {
+	case icon == "":
+		return ""
+	case strings.HasPrefix(icon, "//"):
+		return "https:" + icon
+	case strings.HasPrefix(icon, "http://"):
+		return "https://" + strings.TrimPrefix(icon, "http://")
+	}
+	return icon
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"strings"
+	"sync"
+
+	"weather-mcp-server/i18n"
+)
+
+// logLevels niveles de log de MCP (los de syslog), de menor a mayor severidad
+var logLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// Niveles usados por los eventos del servidor (índices de logLevels)
+const (
+	logDebug = iota
+	logInfo
+	logNotice
+	logWarning
+	logError
+)
+
+// defaultLogLevel nivel de las sesiones que todavía no usaron logging/setLevel
+const defaultLogLevel = logWarning
+
+// LogMessage parámetros de notifications/message
+type LogMessage struct {
+	Level  string                 `json:"level"`
+	Logger string                 `json:"logger"`
+	Data   map[string]interface{} `json:"data"`
+}
+
+// logSession nivel mínimo de los eventos que recibe una sesión y el idioma de sus mensajes
+type logSession struct {
+	level int
+	lang  string
+}
+
+// Sesiones que reciben los eventos del servidor; los servidores indican cómo enviarlos
+// con SetLogNotify
+var (
+	logMu       sync.Mutex
+	logNotify   func(session string, message LogMessage)
+	logSessions = map[string]*logSession{}
+)
+
+// SetLogNotify indica cómo enviar notifications/message a una sesión
+func SetLogNotify(notify func(session string, message LogMessage)) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	logNotify = notify
+}
+
+// OpenLogSession registra una sesión con el nivel por defecto (warning) y fija el
+// idioma de sus mensajes; si ya estaba registrada conserva el nivel
+func OpenLogSession(session, lang string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	if s, ok := logSessions[session]; ok {
+		s.lang = lang
+		return
+	}
+	logSessions[session] = &logSession{level: defaultLogLevel, lang: lang}
+}
+
+// CloseLogSession deja de enviar eventos a una sesión (ej: al cerrarse)
+func CloseLogSession(session string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	delete(logSessions, session)
+}
+
+// SetLogLevel fija el nivel mínimo de los eventos que recibe una sesión; los mensajes
+// siguen en el idioma de tr
+func SetLogLevel(tr i18n.Translator, session, level string) error {
+	for i, name := range logLevels {
+		if name == level {
+			logMu.Lock()
+			defer logMu.Unlock()
+			logSessions[session] = &logSession{level: i, lang: tr.Lang}
+			return nil
+		}
+	}
+	return tr.Errorf("error.log_level", level, strings.Join(logLevels, ", "))
+}
+
+// logEvent envía un evento a las sesiones cuyo nivel lo incluye. data lleva en
+// "message" el texto de la clave key del catálogo, en el idioma de cada sesión, y los
+// detalles en el resto de las claves
+func logEvent(level int, logger, key string, fields map[string]interface{}) {
+	logMu.Lock()
+	notify := logNotify
+	sessions := map[string]string{}
+	for session, s := range logSessions {
+		if level >= s.level {
+			sessions[session] = s.lang
+		}
+	}
+	logMu.Unlock()
+
+	if notify == nil || len(sessions) == 0 {
+		return
+	}
+
+	// Un mensaje por idioma: las sesiones del mismo idioma comparten los datos
+	byLang := map[string]map[string]interface{}{}
+	for session, lang := range sessions {
+		data, ok := byLang[lang]
+		if !ok {
+			data = map[string]interface{}{"message": i18n.For(lang).T(key)}
+			for name, value := range fields {
+				data[name] = value
+			}
+			byLang[lang] = data
+		}
+		notify(session, LogMessage{Level: logLevels[level], Logger: logger, Data: data})
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+
+	"weather-mcp-server/i18n"
+)
+
+func TestLogEvent(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string][]LogMessage{}
+	SetLogNotify(func(session string, message LogMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		received[session] = append(received[session], message)
+	})
+	defer SetLogNotify(nil)
+
+	OpenLogSession("es", "es")
+	OpenLogSession("en", "en")
+	defer CloseLogSession("es")
+	defer CloseLogSession("en")
+	if err := SetLogLevel(i18n.For("en"), "en", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLogLevel(i18n.For("en"), "en", "verbose"); err == nil {
+		t.Errorf("SetLogLevel(verbose): se esperaba un error")
+	}
+
+	logEvent(logDebug, "cache", "log.cache_hit", map[string]interface{}{"url": "forecast.json"})
+	logEvent(logError, "weatherapi", "log.api_error", map[string]interface{}{"status": 500})
+
+	tests := []struct {
+		session  string
+		messages []string
+	}{
+		// La sesión en español sigue en warning: no recibe el evento de debug
+		{"es", []string{"WeatherAPI respondió con error"}},
+		{"en", []string{"Response taken from the cache", "WeatherAPI responded with an error"}},
+	}
+	for _, tt := range tests {
+		got := received[tt.session]
+		if len(got) != len(tt.messages) {
+			t.Fatalf("sesión %s: %d eventos, se esperaban %d", tt.session, len(got), len(tt.messages))
+		}
+		for i, message := range tt.messages {
+			if got[i].Data["message"] != message {
+				t.Errorf("sesión %s, evento %d: %q, se esperaba %q", tt.session, i, got[i].Data["message"], message)
+			}
+		}
+	}
+	if got := received["es"][0]; got.Level != "error" || got.Logger != "weatherapi" || got.Data["status"] != 500 {
+		t.Errorf("evento = %+v", got)
+	}
+
+	// Reabrir una sesión cambia el idioma pero conserva el nivel
+	OpenLogSession("en", "es")
+	logEvent(logDebug, "cache", "log.cache_miss", nil)
+	if got := received["en"]; len(got) != 3 || got[2].Data["message"] != "Respuesta no encontrada en la caché" {
+		t.Errorf("después de reabrir la sesión: %+v", got)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"strings"
+
+	"weather-mcp-server/chart"
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/units"
+)
+
+// stringListParam lee un parámetro que puede venir como lista JSON o como texto separado por ';'
+func stringListParam(tr i18n.Translator, params map[string]interface{}, key string) ([]string, error) {
+	raw, exists := params[key]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+
+	var values []string
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, tr.Errorf("error.param_list", key)
+			}
+			values = append(values, str)
+		}
+	case []string:
+		values = v
+	case string:
+		values = strings.Split(v, ";")
+	default:
+		return nil, tr.Errorf("error.param_list", key)
+	}
+
+	cleaned := values[:0:0]
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			cleaned = append(cleaned, value)
+		}
+	}
+	return cleaned, nil
+}
+
+// intParam lee un parámetro numérico opcional; devuelve def si no está presente
+func intParam(params map[string]interface{}, key string, def int) int {
+	if raw, exists := params[key]; exists {
+		if num, ok := raw.(float64); ok {
+			return int(num)
+		}
+	}
+	return def
+}
+
+// boolParam lee un parámetro booleano opcional; acepta true/false o "yes"/"no"
+func boolParam(params map[string]interface{}, key string, def bool) bool {
+	switch value := params[key].(type) {
+	case bool:
+		return value
+	case string:
+		switch strings.ToLower(value) {
+		case "yes", "true", "1":
+			return true
+		case "no", "false", "0":
+			return false
+		}
+	}
+	return def
+}
+
+// stringParam lee un parámetro de texto opcional; devuelve def si no está presente o está vacío
+func stringParam(params map[string]interface{}, key string, def string) string {
+	if raw, exists := params[key]; exists {
+		if str, ok := raw.(string); ok && str != "" {
+			return str
+		}
+	}
+	return def
+}
+
+// unitsParam lee el parámetro 'units'; sin él usa el sistema por defecto de la configuración
+func unitsParam(cfg *config.Config, tr i18n.Translator, params map[string]interface{}) (units.System, error) {
+	name := stringParam(params, "units", "")
+	if name == "" {
+		return cfg.Units, nil
+	}
+	system, err := units.Parse(name)
+	if err != nil {
+		return system, tr.Errorf("error.unknown_units", name, strings.Join(units.Names(), ", "))
+	}
+	return system, nil
+}
+
+// langParam lee el parámetro 'lang'; sin él usa el idioma por defecto de la configuración
+func langParam(cfg *config.Config, params map[string]interface{}) (i18n.Translator, error) {
+	tr := i18n.For(cfg.Lang)
+	name := stringParam(params, "lang", "")
+	if name == "" {
+		return tr, nil
+	}
+	lang := i18n.Normalize(name)
+	if lang == "" {
+		return tr, tr.Errorf("error.unknown_lang", name, strings.Join(i18n.Languages(), ", "))
+	}
+	return i18n.For(lang), nil
+}
+
+// requiredString lee un parámetro de texto obligatorio
+func requiredString(tr i18n.Translator, params map[string]interface{}, key string) (string, error) {
+	value, ok := params[key].(string)
+	if !ok || value == "" {
+		return "", tr.Errorf("error.required", key)
+	}
+	return value, nil
+}
+
+// chartParam lee el parámetro opcional 'chart' (png o svg); vacío si no se pidió gráfico
+func chartParam(tr i18n.Translator, params map[string]interface{}) (string, error) {
+	format := stringParam(params, "chart", "")
+	if format == "" {
+		return "", nil
+	}
+	for _, f := range chart.Formats() {
+		if f == format {
+			return format, nil
+		}
+	}
+	return "", tr.Errorf("error.unknown_chart", format, strings.Join(chart.Formats(), ", "))
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+)
+
+// ProgressFunc recibe el avance de una herramienta: progress pasos terminados de total
+type ProgressFunc func(progress, total int, message string)
+
+// progressKey clave del ProgressFunc en el contexto
+type progressKey struct{}
+
+// WithProgress devuelve un contexto por el que las herramientas que hacen varias
+// consultas informan su avance (ej: una ubicación de compare_weather)
+func WithProgress(ctx context.Context, report ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// progressCounter cuenta los pasos terminados de una herramienta; se puede usar
+// desde varias goroutines
+type progressCounter struct {
+	report ProgressFunc
+	total  int
+
+	mu   sync.Mutex
+	done int
+}
+
+// newProgress crea el contador de una herramienta de total pasos; si el contexto no
+// pide avance los pasos no se informan
+func newProgress(ctx context.Context, total int) *progressCounter {
+	report, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return &progressCounter{report: report, total: total}
+}
+
+// step marca un paso terminado e informa el avance
+func (p *progressCounter) step(message string) {
+	if p.report == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	p.report(p.done, p.total, message)
+}
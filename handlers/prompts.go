@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"weather-mcp-server/i18n"
+)
+
+// ErrPromptNotFound se devuelve al pedir un prompt que no está registrado
+var ErrPromptNotFound = errors.New("prompt no encontrado")
+
+// PromptDefinition define un prompt MCP
+type PromptDefinition struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Arguments   []PromptArgument `json:"arguments"`
+}
+
+// PromptArgument argumento declarado de un prompt
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// PromptMessage mensaje del resultado de prompts/get
+type PromptMessage struct {
+	Role    string                 `json:"role"`
+	Content map[string]interface{} `json:"content"`
+}
+
+// PromptResult resultado de prompts/get
+type PromptResult struct {
+	Description string          `json:"description"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// promptSpec prompt registrado: sus argumentos y cómo arma el texto. Las descripciones
+// son las claves "prompt.<nombre>" y "prompt.<nombre>.<argumento>" del catálogo
+type promptSpec struct {
+	Name      string
+	Arguments []string
+	Required  map[string]bool
+	Build     func(tr i18n.Translator, args map[string]string) string
+}
+
+// prompts prompts disponibles, en el orden de prompts/list
+var prompts = []promptSpec{
+	{
+		Name:      "daily_briefing",
+		Arguments: []string{"location", "units"},
+		Required:  map[string]bool{"location": true},
+		Build: func(tr i18n.Translator, args map[string]string) string {
+			return promptText(tr, args, tr.T("prompt.daily_briefing.text", args["location"]))
+		},
+	},
+	{
+		Name:      "travel_packing",
+		Arguments: []string{"location", "dates", "activities", "units"},
+		Required:  map[string]bool{"location": true, "dates": true},
+		Build: func(tr i18n.Translator, args map[string]string) string {
+			text := tr.T("prompt.travel_packing.text", args["location"], args["dates"])
+			if activities := args["activities"]; activities != "" {
+				text += "\n\n" + tr.T("prompt.travel_packing.activities", activities)
+			}
+			return promptText(tr, args, text)
+		},
+	},
+	{
+		Name:      "outdoor_event_go_no_go",
+		Arguments: []string{"location", "time", "activity", "units"},
+		Required:  map[string]bool{"location": true, "time": true},
+		Build: func(tr i18n.Translator, args map[string]string) string {
+			activity := args["activity"]
+			if activity == "" {
+				activity = "outdoor_event"
+			}
+			return promptText(tr, args, tr.T("prompt.outdoor_event_go_no_go.text", args["location"], args["time"], activity))
+		},
+	},
+}
+
+// promptText agrega al texto las indicaciones comunes a todos los prompts
+func promptText(tr i18n.Translator, args map[string]string, text string) string {
+	if units := args["units"]; units != "" {
+		text += "\n\n" + tr.T("prompt.units", units)
+	}
+	return text
+}
+
+// PromptDefinitions devuelve las definiciones de todos los prompts con las
+// descripciones en el idioma del traductor
+func PromptDefinitions(tr i18n.Translator) []PromptDefinition {
+	definitions := make([]PromptDefinition, 0, len(prompts))
+	for _, p := range prompts {
+		definition := PromptDefinition{
+			Name:        p.Name,
+			Description: tr.T("prompt." + p.Name),
+		}
+		for _, arg := range p.Arguments {
+			definition.Arguments = append(definition.Arguments, PromptArgument{
+				Name:        arg,
+				Description: tr.T("prompt." + p.Name + "." + arg),
+				Required:    p.Required[arg],
+			})
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions
+}
+
+// GetPrompt arma el prompt indicado con sus argumentos; devuelve ErrPromptNotFound
+// si no existe
+func GetPrompt(tr i18n.Translator, name string, arguments map[string]interface{}) (*PromptResult, error) {
+	for _, p := range prompts {
+		if p.Name != name {
+			continue
+		}
+
+		args := map[string]string{}
+		for _, arg := range p.Arguments {
+			value, _ := arguments[arg].(string)
+			value = strings.TrimSpace(value)
+			if value == "" && p.Required[arg] {
+				return nil, tr.Errorf("error.required", arg)
+			}
+			args[arg] = value
+		}
+
+		return &PromptResult{
+			Description: tr.T("prompt." + p.Name),
+			Messages: []PromptMessage{{
+				Role:    "user",
+				Content: map[string]interface{}{"type": "text", "text": p.Build(tr, args)},
+			}},
+		}, nil
+	}
+	return nil, ErrPromptNotFound
+}
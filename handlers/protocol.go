@@ -0,0 +1,57 @@
+package handlers
+
+// Versiones del protocolo MCP que entiende el servidor. Las fechas se comparan como
+// texto: una versión más nueva es mayor
+const (
+	// ProtocolLatest agrega elicitation/create, el contenido resource_link y
+	// structuredContent
+	ProtocolLatest = "2025-06-18"
+	// ProtocolHTTPDefault versión que se supone por HTTP si la solicitud no trae la
+	// cabecera MCP-Protocol-Version
+	ProtocolHTTPDefault = "2025-03-26"
+	// protocolOldest primera versión publicada
+	protocolOldest = "2024-11-05"
+)
+
+// supportedProtocols versiones que se pueden negociar, de la más nueva a la más antigua
+var supportedProtocols = []string{ProtocolLatest, ProtocolHTTPDefault, protocolOldest}
+
+// NegotiateProtocol versión que se usa con el cliente: la que pidió si el servidor la
+// entiende; si no, la más nueva (y el cliente decide si puede seguir)
+func NegotiateProtocol(requested string) string {
+	for _, version := range supportedProtocols {
+		if version == requested {
+			return version
+		}
+	}
+	return ProtocolLatest
+}
+
+// SupportsElicitation si la versión negociada permite enviar elicitation/create
+func SupportsElicitation(version string) bool {
+	return version >= ProtocolLatest
+}
+
+// SupportsResourceLinks si la versión negociada tiene el contenido resource_link
+func SupportsResourceLinks(version string) bool {
+	return version >= ProtocolLatest
+}
+
+// InitializeResult respuesta de initialize con la versión negociada y las capacidades
+// del servidor
+func InitializeResult(version string) map[string]interface{} {
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools":       map[string]interface{}{},
+			"resources":   map[string]interface{}{"subscribe": true},
+			"prompts":     map[string]interface{}{},
+			"completions": map[string]interface{}{},
+			"logging":     map[string]interface{}{},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    "weather-mcp-server",
+			"version": "1.0.0",
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import "testing"
+
+func TestNegotiateProtocol(t *testing.T) {
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		{"2025-06-18", "2025-06-18"},
+		{"2025-03-26", "2025-03-26"},
+		{"2024-11-05", "2024-11-05"},
+		{"2099-01-01", ProtocolLatest},
+		{"", ProtocolLatest},
+	}
+	for _, tt := range tests {
+		if got := NegotiateProtocol(tt.requested); got != tt.want {
+			t.Errorf("NegotiateProtocol(%q) = %q, se esperaba %q", tt.requested, got, tt.want)
+		}
+	}
+}
+
+func TestSupportsElicitation(t *testing.T) {
+	for version, want := range map[string]bool{
+		"2025-06-18": true,
+		"2025-03-26": false,
+		"2024-11-05": false,
+		"":           false,
+	} {
+		if got := SupportsElicitation(version); got != want {
+			t.Errorf("SupportsElicitation(%q) = %v, se esperaba %v", version, got, want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+)
+
+// Tipos de 'location' que reconoce el resolver
+const (
+	locationKindName        = "name"
+	locationKindPostalCode  = "postal_code"
+	locationKindCoordinates = "coordinates"
+	locationKindAirport     = "airport"
+	locationKindID          = "id"
+	locationKindIP          = "ip"
+)
+
+// maxResolvedLocations ubicaciones resueltas que se recuerdan; al llenarse se olvidan
+// las más antiguas
+const maxResolvedLocations = 500
+
+// Formatos de código postal que acepta WeatherAPI: Estados Unidos, Reino Unido y Canadá
+var postalCodePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+	regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`),
+}
+
+// coordinatesPattern texto con forma "lat,lon"; parseLatLon comprueba los rangos
+var coordinatesPattern = regexp.MustCompile(`^[-+]?\d+(\.\d+)?\s*,\s*[-+]?\d+(\.\d+)?$`)
+
+// LocationIdentity identidad canónica de una ubicación resuelta: el ID de WeatherAPI
+// y Ref, el valor de 'location' que devuelve la misma ubicación en las llamadas
+// siguientes ("id:<n>", o las coordenadas o el código de aeropuerto si se pidió así)
+type LocationIdentity struct {
+	ID  int    `json:"id,omitempty"`
+	Ref string `json:"ref,omitempty"`
+}
+
+// canonicalLocation ubicación resuelta: identidad y, si se conocen, nombre,
+// coordenadas y zona horaria. Los identificadores (id:, aeropuertos, códigos postales)
+// solo tienen Ref; located indica que además hay coordenadas
+type canonicalLocation struct {
+	LocationIdentity
+	Name    string
+	Region  string
+	Country string
+	Lat     float64
+	Lon     float64
+	TzID    string
+	located bool
+	// Alternatives otras ubicaciones con el mismo nombre cuando se usó la primera sin
+	// preguntar al usuario
+	Alternatives []LocationCandidate
+}
+
+// label nombre legible "Ciudad, Región, País"
+func (c *canonicalLocation) label() string {
+	return placeLabel(PlaceData{Name: c.Name, Region: c.Region, Country: c.Country})
+}
+
+// locationInput 'location' normalizada: su tipo y el valor para el parámetro q de
+// WeatherAPI
+type locationInput struct {
+	Kind  string
+	Query string
+}
+
+// key clave de la ubicación en el registro de resueltas
+func (in locationInput) key() string {
+	return in.Kind + "|" + strings.ToLower(in.Query)
+}
+
+// parseLocationInput reconoce el tipo de una 'location' y la normaliza: espacios de
+// más, coordenadas redondeadas, códigos postales en mayúsculas y prefijos de WeatherAPI
+// (iata:, metar:) para los aeropuertos. Los aeropuertos llevan siempre prefijo: un
+// código suelto como "NYC" o "LIMA" es un nombre. Devuelve error si son coordenadas fuera de rango
+// o un id: que no es un número
+func parseLocationInput(tr i18n.Translator, location string) (locationInput, error) {
+	value := strings.Join(strings.Fields(location), " ")
+	lower := strings.ToLower(value)
+
+	switch {
+	case strings.HasPrefix(lower, "id:"):
+		id, err := strconv.Atoi(strings.TrimSpace(value[3:]))
+		if err != nil || id <= 0 {
+			return locationInput{}, tr.Errorf("resolver.error_id", value)
+		}
+		return locationInput{Kind: locationKindID, Query: fmt.Sprintf("id:%d", id)}, nil
+	case lower == "auto:ip" || net.ParseIP(value) != nil:
+		return locationInput{Kind: locationKindIP, Query: lower}, nil
+	case strings.HasPrefix(lower, "iata:"):
+		return locationInput{Kind: locationKindAirport, Query: "iata:" + strings.ToUpper(strings.TrimSpace(value[5:]))}, nil
+	case strings.HasPrefix(lower, "icao:"), strings.HasPrefix(lower, "metar:"):
+		code := value[strings.Index(value, ":")+1:]
+		return locationInput{Kind: locationKindAirport, Query: "metar:" + strings.ToUpper(strings.TrimSpace(code))}, nil
+	}
+
+	if coordinatesPattern.MatchString(value) {
+		lat, lon, ok := parseLatLon(value)
+		if !ok {
+			return locationInput{}, tr.Errorf("resolver.error_coordinates", value)
+		}
+		return locationInput{Kind: locationKindCoordinates, Query: formatLatLon(lat, lon)}, nil
+	}
+
+	upper := strings.ToUpper(value)
+	for _, pattern := range postalCodePatterns {
+		if pattern.MatchString(upper) {
+			return locationInput{Kind: locationKindPostalCode, Query: upper}, nil
+		}
+	}
+	return locationInput{Kind: locationKindName, Query: value}, nil
+}
+
+// resolvedLocations nombres que coinciden con una sola ubicación de WeatherAPI. Son
+// los únicos que se recuerdan: su identidad es la misma para cualquier sesión, en
+// cambio la ubicación elegida entre varias puede ser otra para otro cliente
+var resolvedLocations = &locationRegistry{entries: map[string]*canonicalLocation{}}
+
+// locationRegistry registro acotado de ubicaciones resueltas; una ubicación no cambia
+// de identidad, así que no vence
+type locationRegistry struct {
+	mu      sync.Mutex
+	entries map[string]*canonicalLocation
+	order   []string
+}
+
+// get devuelve una copia de la ubicación resuelta para la clave
+func (r *locationRegistry) get(key string) (*canonicalLocation, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	location, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+	copied := *location
+	return &copied, true
+}
+
+// put guarda una copia de la ubicación con la clave
+func (r *locationRegistry) put(key string, location *canonicalLocation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.entries[key]; !exists {
+		r.order = append(r.order, key)
+	}
+	copied := *location
+	r.entries[key] = &copied
+	for len(r.order) > maxResolvedLocations {
+		delete(r.entries, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// resolveLocation resuelve una 'location' a su ubicación canónica. Los
+// identificadores (id:, coordenadas, aeropuertos y códigos postales) ya son estables:
+// se usan como Ref sin consultar a WeatherAPI. Un nombre cuesta una consulta a
+// search.json (la misma que aclara los ambiguos) y toma la identidad de la ubicación
+// que se llama así; si hay varias, con disambiguate se aclara como en
+// disambiguateLocation (y puede devolver el resultado con las candidatas) y sin
+// disambiguate se usa la primera y las demás quedan en Alternatives. Devuelve nil, y
+// la herramienta sigue con la ubicación tal cual, para las IPs (cambian con el
+// cliente) y los nombres sin coincidencia exacta
+func resolveLocation(ctx context.Context, cfg *config.Config, tr i18n.Translator, location string, disambiguate bool) (*canonicalLocation, *ToolResult, error) {
+	input, err := parseLocationInput(tr, location)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch input.Kind {
+	case locationKindIP:
+		return nil, nil, nil
+	case locationKindName:
+	default:
+		return identifierLocation(cfg, input), nil, nil
+	}
+
+	if resolved, ok := resolvedLocations.get(input.key()); ok {
+		return resolved, nil, nil
+	}
+	candidates, err := locationCandidates(ctx, cfg, tr, input.Query)
+	if err != nil || len(candidates) == 0 {
+		return nil, nil, err
+	}
+
+	if len(candidates) == 1 {
+		resolved := candidateLocation(cfg, candidates[0])
+		// Los lugares del gazetteer no tienen ID: se vuelven a buscar por si WeatherAPI
+		// responde la próxima vez
+		if resolved.ID > 0 {
+			resolvedLocations.put(input.key(), resolved)
+		}
+		return resolved, nil, nil
+	}
+
+	if !disambiguate {
+		resolved := candidateLocation(cfg, candidates[0])
+		resolved.Alternatives = candidates[1:]
+		return resolved, nil, nil
+	}
+	choice, alternatives, hint, err := disambiguateLocation(ctx, tr, input.Query, candidates)
+	if choice == nil {
+		return nil, hint, err
+	}
+	resolved := candidateLocation(cfg, *choice)
+	resolved.Alternatives = alternatives
+	return resolved, nil, nil
+}
+
+// identifierLocation ubicación canónica de un identificador: su Ref es la entrada
+// normalizada. Unas coordenadas toman el nombre y la zona horaria del lugar del
+// gazetteer que esté cerca, si hay uno
+func identifierLocation(cfg *config.Config, input locationInput) *canonicalLocation {
+	resolved := &canonicalLocation{LocationIdentity: LocationIdentity{Ref: input.Query}}
+	switch input.Kind {
+	case locationKindID:
+		resolved.ID, _ = strconv.Atoi(strings.TrimPrefix(input.Query, "id:"))
+	case locationKindCoordinates:
+		resolved.Lat, resolved.Lon, _ = parseLatLon(input.Query)
+		resolved.located = true
+		if place, ok := gazetteerNearby(cfg.Gazetteer, resolved.Lat, resolved.Lon); ok {
+			resolved.Name, resolved.Region, resolved.Country = place.Name, place.Region, place.Country
+			resolved.TzID = place.TzID
+		}
+	}
+	return resolved
+}
+
+// candidateLocation ubicación canónica de una coincidencia de search.json o del
+// gazetteer; las del gazetteer toman de él la zona horaria
+func candidateLocation(cfg *config.Config, c LocationCandidate) *canonicalLocation {
+	resolved := &canonicalLocation{
+		LocationIdentity: LocationIdentity{ID: c.ID, Ref: c.Location},
+		Name:             c.Name,
+		Region:           c.Region,
+		Country:          c.Country,
+		Lat:              c.Lat,
+		Lon:              c.Lon,
+		located:          true,
+	}
+	if c.ID == 0 {
+		if place, ok := gazetteerNearby(cfg.Gazetteer, c.Lat, c.Lon); ok {
+			resolved.TzID = place.TzID
+		}
+	}
+	return resolved
+}
+
+// resolveToolLocation resuelve el parámetro 'location' de una herramienta y lo
+// reemplaza por su Ref; la ubicación canónica queda en el contexto para los
+// resultados. Si la ubicación es ambigua devuelve el resultado con las candidatas. Si
+// no se puede resolver (ej: WeatherAPI no responde) la herramienta sigue con la
+// ubicación tal cual
+func resolveToolLocation(ctx context.Context, cfg *config.Config, tr i18n.Translator, params map[string]interface{}) (context.Context, *ToolResult, error) {
+	location, _ := params["location"].(string)
+	if strings.TrimSpace(location) == "" {
+		return ctx, nil, nil
+	}
+	if _, err := parseLocationInput(tr, location); err != nil {
+		return ctx, nil, err
+	}
+
+	resolved, hint, err := resolveLocation(ctx, cfg, tr, location, true)
+	switch {
+	case hint != nil:
+		return ctx, hint, nil
+	case ctx.Err() != nil:
+		return ctx, nil, ctx.Err()
+	case err != nil:
+		logEvent(logNotice, "resolver", "log.resolver_unresolved", map[string]interface{}{
+			"location": location,
+			"error":    err.Error(),
+		})
+		return ctx, nil, nil
+	case resolved == nil:
+		return ctx, nil, nil
+	}
+
+	params["location"] = resolved.Ref
+	return context.WithValue(ctx, canonicalKey{}, resolved), nil, nil
+}
+
+// canonicalKey clave de la ubicación canónica de la herramienta en el contexto
+type canonicalKey struct{}
+
+// canonicalFrom ubicación canónica de la herramienta en curso; nil si no se resolvió
+func canonicalFrom(ctx context.Context) *canonicalLocation {
+	resolved, _ := ctx.Value(canonicalKey{}).(*canonicalLocation)
+	return resolved
+}
+
+// locationIdentity identidad de la ubicación de la herramienta en curso; vacía si no
+// se resolvió
+func locationIdentity(ctx context.Context) LocationIdentity {
+	if resolved := canonicalFrom(ctx); resolved != nil {
+		return resolved.LocationIdentity
+	}
+	return LocationIdentity{}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/gazetteer"
+	"weather-mcp-server/i18n"
+)
+
+func TestParseLocationInput(t *testing.T) {
+	tr := i18n.For("es")
+	tests := []struct {
+		location string
+		kind     string
+		query    string
+	}{
+		{"Madrid", locationKindName, "Madrid"},
+		{"  buenos   aires ", locationKindName, "buenos aires"},
+		{"NYC", locationKindName, "NYC"},
+		{"LIMA", locationKindName, "LIMA"},
+		{"BAKU", locationKindName, "BAKU"},
+		{"iata:jfk", locationKindAirport, "iata:JFK"},
+		{"icao:LEMD", locationKindAirport, "metar:LEMD"},
+		{"metar:egll", locationKindAirport, "metar:EGLL"},
+		{"id:2801268", locationKindID, "id:2801268"},
+		{"ID: 42", locationKindID, "id:42"},
+		{"40.41678,-3.70379", locationKindCoordinates, "40.4168,-3.7038"},
+		{"-34.6, -58.38", locationKindCoordinates, "-34.6000,-58.3800"},
+		{"10001", locationKindPostalCode, "10001"},
+		{"sw1a 1aa", locationKindPostalCode, "SW1A 1AA"},
+		{"h2x 1y4", locationKindPostalCode, "H2X 1Y4"},
+		{"auto:ip", locationKindIP, "auto:ip"},
+		{"8.8.8.8", locationKindIP, "8.8.8.8"},
+	}
+	for _, tt := range tests {
+		input, err := parseLocationInput(tr, tt.location)
+		if err != nil {
+			t.Errorf("parseLocationInput(%q): %v", tt.location, err)
+			continue
+		}
+		if input.Kind != tt.kind || input.Query != tt.query {
+			t.Errorf("parseLocationInput(%q) = {%s %q}, se esperaba {%s %q}", tt.location, input.Kind, input.Query, tt.kind, tt.query)
+		}
+	}
+}
+
+func TestParseLocationInputErrors(t *testing.T) {
+	tr := i18n.For("es")
+	for _, location := range []string{"id:abc", "id:0", "id:-3", "91,0", "0,181"} {
+		if input, err := parseLocationInput(tr, location); err == nil {
+			t.Errorf("parseLocationInput(%q) = %+v, se esperaba un error", location, input)
+		}
+	}
+}
+
+func TestLocationRegistry(t *testing.T) {
+	r := &locationRegistry{entries: map[string]*canonicalLocation{}}
+	madrid := &canonicalLocation{LocationIdentity: LocationIdentity{ID: 1, Ref: "id:1"}, Name: "Madrid"}
+	r.put("name|madrid", madrid)
+
+	// Lo que se guarda y lo que se devuelve son copias: quien lo modifica no cambia el registro
+	madrid.Name = "Otro"
+	got, ok := r.get("name|madrid")
+	if !ok || got.Name != "Madrid" {
+		t.Fatalf("get = %+v, %v; se esperaba Madrid", got, ok)
+	}
+	got.Ref = "id:2"
+	if again, _ := r.get("name|madrid"); again.Ref != "id:1" {
+		t.Errorf("el registro cambió al modificar la copia: %+v", again)
+	}
+
+	for i := 0; i < maxResolvedLocations; i++ {
+		r.put(fmt.Sprintf("name|%d", i), &canonicalLocation{})
+	}
+	if _, ok := r.get("name|madrid"); ok {
+		t.Errorf("la entrada más antigua no se olvidó al llenarse el registro")
+	}
+	if len(r.entries) != maxResolvedLocations || len(r.order) != maxResolvedLocations {
+		t.Errorf("el registro tiene %d entradas y %d en orden; máximo %d", len(r.entries), len(r.order), maxResolvedLocations)
+	}
+}
+
+func TestLocationCandidatesCached(t *testing.T) {
+	// WeatherAPI no responde: las candidatas salen del gazetteer, que la caché de
+	// respuestas no guarda
+	var searches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&searches, 1)
+		http.Error(w, `{"error":{"code":9999,"message":"Internal application error."}}`, http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cities := filepath.Join(t.TempDir(), "cities15000.txt")
+	lines := []string{
+		"3871336\tSantiago\tSantiago\t\t-33.45694\t-70.64827\tP\tPPLC\tCL\t\t12\t\t\t\t4837295\t\t520\tAmerica/Santiago\t2024-01-01",
+		"3109642\tSantiago\tSantiago\t\t42.88052\t-8.54569\tP\tPPLA\tES\t\t58\t\t\t\t95092\t\t260\tEurope/Madrid\t2024-01-01",
+	}
+	if err := os.WriteFile(cities, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := gazetteer.Load(cities)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Caché propia para la prueba: la compartida se crea con el TTL de la primera config
+	cfg := &config.Config{BaseURL: server.URL, WeatherAPIKey: "test", RateLimit: 60, Gazetteer: g, GazetteerMode: config.GazetteerFallback}
+	upstream(cfg)
+	shared := upstreamCache
+	upstreamCache = &responseCache{ttl: time.Minute, entries: map[string]cacheEntry{}}
+	defer func() { upstreamCache = shared }()
+
+	previous := apiRetryDelay
+	apiRetryDelay = time.Millisecond
+	defer func() { apiRetryDelay = previous }()
+
+	tr := i18n.For("es")
+	var first int32
+	for i := 0; i < 3; i++ {
+		resolved, _, err := resolveLocation(context.Background(), cfg, tr, "Santiago", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resolved.Country != "CL" || len(resolved.Alternatives) != 1 || resolved.Alternatives[0].Country != "ES" {
+			t.Errorf("llamada %d: %+v, se esperaba Santiago de Chile con el de España como alternativa", i+1, resolved)
+		}
+		if i == 0 {
+			first = atomic.LoadInt32(&searches)
+		}
+	}
+	if searches != first {
+		t.Errorf("search.json se consultó %d veces, se esperaba solo en la primera llamada (%d)", searches, first)
+	}
+}
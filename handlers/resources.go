@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/render"
+)
+
+// ErrResourceNotFound se devuelve al leer una URI que no corresponde a ningún recurso
+var ErrResourceNotFound = errors.New("recurso no encontrado")
+
+// resourceScheme esquema de las URIs de los recursos del servidor
+const resourceScheme = "weather"
+
+// Resource recurso concreto publicado en resources/list
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate plantilla de URI (RFC 6570) publicada en resources/templates/list
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContent contenido de un recurso devuelto por resources/read
+type ResourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// resourceKind tipo de recurso: la herramienta que lo genera y cómo interpretar
+// los segmentos de la URI después de la ubicación
+type resourceKind struct {
+	Tool     string
+	Template string
+	Params   func(tr i18n.Translator, segments []string) (map[string]interface{}, error)
+}
+
+// resourceKinds tipos de recurso por host de la URI (weather://<host>/...)
+var resourceKinds = map[string]resourceKind{
+	"current": {
+		Tool:     "get_current_weather",
+		Template: "weather://current/{location}",
+		Params: func(tr i18n.Translator, segments []string) (map[string]interface{}, error) {
+			if len(segments) != 0 {
+				return nil, ErrResourceNotFound
+			}
+			return map[string]interface{}{}, nil
+		},
+	},
+	"forecast": {
+		Tool:     "get_forecast",
+		Template: "weather://forecast/{location}/{days}",
+		Params: func(tr i18n.Translator, segments []string) (map[string]interface{}, error) {
+			if len(segments) != 1 {
+				return nil, ErrResourceNotFound
+			}
+			days, err := strconv.Atoi(segments[0])
+			if err != nil || days < 1 || days > 10 {
+				return nil, tr.Errorf("error.range", "days", 1, 10)
+			}
+			return map[string]interface{}{"days": float64(days)}, nil
+		},
+	},
+	"astronomy": {
+		Tool:     "get_astronomy",
+		Template: "weather://astronomy/{location}/{date}",
+		Params: func(tr i18n.Translator, segments []string) (map[string]interface{}, error) {
+			if len(segments) != 1 {
+				return nil, ErrResourceNotFound
+			}
+			if segments[0] == "today" {
+				return map[string]interface{}{}, nil
+			}
+			if _, err := time.Parse("2006-01-02", segments[0]); err != nil {
+				return nil, tr.Errorf("error.date_format", "date")
+			}
+			return map[string]interface{}{"date": segments[0]}, nil
+		},
+	},
+}
+
+// resourceKindOrder orden de los tipos en los listados
+var resourceKindOrder = []string{"current", "forecast", "astronomy"}
+
+// resourceQueryParams parámetros de herramienta aceptados en la query de la URI
+// (ej: weather://current/Madrid?units=imperial&format=markdown)
+var resourceQueryParams = []string{"units", "lang", "format"}
+
+// ResourceTemplates devuelve las plantillas de URI con las descripciones en el
+// idioma del traductor
+func ResourceTemplates(tr i18n.Translator) []ResourceTemplate {
+	var templates []ResourceTemplate
+	for _, key := range resourceKindOrder {
+		templates = append(templates, ResourceTemplate{
+			URITemplate: resourceKinds[key].Template,
+			Name:        key,
+			Description: tr.T("resource." + key),
+			MimeType:    "application/json",
+		})
+	}
+	return templates
+}
+
+// ListResources devuelve los recursos de las ubicaciones configuradas
+// (WEATHER_RESOURCE_LOCATIONS): clima actual, pronóstico de 3 días y astronomía de hoy
+func ListResources(cfg *config.Config, tr i18n.Translator) []Resource {
+	resources := []Resource{}
+	for _, location := range cfg.ResourceLocations {
+		for _, key := range resourceKindOrder {
+			uri := strings.Replace(resourceKinds[key].Template, "{location}", url.PathEscape(location), 1)
+			uri = strings.Replace(uri, "{days}", "3", 1)
+			uri = strings.Replace(uri, "{date}", "today", 1)
+			resources = append(resources, Resource{
+				URI:         uri,
+				Name:        tr.T("resource."+key+".name", location),
+				Description: tr.T("resource." + key),
+				MimeType:    "application/json",
+			})
+		}
+	}
+	return resources
+}
+
+// parseResourceURI separa una URI weather://<tipo>/<ubicación>/... en el tipo, los
+// segmentos de la ruta (el primero es la ubicación) y la query
+func parseResourceURI(uri string) (string, []string, url.Values, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != resourceScheme {
+		return "", nil, nil, ErrResourceNotFound
+	}
+	if _, ok := resourceKinds[parsed.Host]; !ok {
+		return "", nil, nil, ErrResourceNotFound
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if segments[0] == "" {
+		return "", nil, nil, ErrResourceNotFound
+	}
+	return parsed.Host, segments, parsed.Query(), nil
+}
+
+// ReadResource lee un recurso ejecutando la herramienta correspondiente; devuelve
+// el resultado estructurado en JSON y su presentación en texto. Devuelve
+// ErrResourceNotFound si la URI no corresponde a ninguna plantilla
+func ReadResource(ctx context.Context, cfg *config.Config, tr i18n.Translator, uri string) ([]ResourceContent, error) {
+	host, segments, query, err := parseResourceURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	kind := resourceKinds[host]
+	params, err := kind.Params(tr, segments[1:])
+	if err != nil {
+		return nil, err
+	}
+	params["location"] = segments[0]
+	params["lang"] = tr.Lang
+	for _, key := range resourceQueryParams {
+		if value := query.Get(key); value != "" {
+			params[key] = value
+		}
+	}
+
+	result, err := CallTool(ctx, cfg, kind.Tool, params)
+	if err != nil {
+		return nil, err
+	}
+	toolResult, ok := result.(*ToolResult)
+	if !ok {
+		return nil, ErrResourceNotFound
+	}
+
+	data, err := json.MarshalIndent(toolResult.Structured, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	textMimeType := "text/plain"
+	if stringParam(params, "format", cfg.Format) == render.FormatMarkdown {
+		textMimeType = "text/markdown"
+	}
+	return []ResourceContent{
+		{URI: uri, MimeType: "application/json", Text: string(data)},
+		{URI: uri, MimeType: textMimeType, Text: toolResult.Text},
+	}, nil
+}
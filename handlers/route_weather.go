@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/gazetteer"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+	"weather-mcp-server/render"
+	"weather-mcp-server/units"
+)
+
+// maxRouteWaypoints límite de puntos de paso por ruta
+const maxRouteWaypoints = 20
+
+// RouteWaypoint condiciones previstas en un punto de paso al momento de llegar.
+// Las mediciones están en el sistema de unidades del resultado
+type RouteWaypoint struct {
+	LocationIdentity
+	Query        string   `json:"query"`
+	Name         string   `json:"name"`
+	Region       string   `json:"region,omitempty"`
+	Country      string   `json:"country,omitempty"`
+	Lat          float64  `json:"lat"`
+	Lon          float64  `json:"lon"`
+	Distance     float64  `json:"distance"`
+	ETA          string   `json:"eta"`
+	ETAEpoch     int64    `json:"eta_epoch"`
+	ForecastTime string   `json:"forecast_time,omitempty"`
+	Condition    string   `json:"condition,omitempty"`
+	Temp         float64  `json:"temperature"`
+	Wind         float64  `json:"wind"`
+	Gust         float64  `json:"gust"`
+	ChanceOfRain float64  `json:"chance_of_rain"`
+	ChanceOfSnow float64  `json:"chance_of_snow"`
+	Precip       float64  `json:"precipitation"`
+	Visibility   float64  `json:"visibility"`
+	Hazards      []string `json:"hazards,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	// Alternatives otras ubicaciones con el mismo nombre si la consulta era ambigua
+	Alternatives []LocationCandidate `json:"alternatives,omitempty"`
+}
+
+// RouteSegment tramo entre dos puntos de paso consecutivos
+type RouteSegment struct {
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Distance  float64  `json:"distance"`
+	Minutes   int      `json:"minutes"`
+	Hazardous bool     `json:"hazardous"`
+	Hazards   []string `json:"hazards,omitempty"`
+}
+
+// RouteWeatherResult resultado estructurado de get_route_weather
+type RouteWeatherResult struct {
+	Departure     string          `json:"departure"`
+	Units         units.System    `json:"units"`
+	Speed         float64         `json:"speed"`
+	RoadFactor    float64         `json:"road_factor"`
+	TotalDistance float64         `json:"total_distance"`
+	Waypoints     []RouteWaypoint `json:"waypoints"`
+	Segments      []RouteSegment  `json:"segments"`
+}
+
+// GetRouteWeather estima la llegada a cada punto de paso y devuelve el pronóstico
+// horario esperado en ese punto, marcando los tramos con condiciones peligrosas
+func GetRouteWeather(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error) {
+	tr, err := langParam(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+
+	queries, err := stringListParam(tr, params, "waypoints")
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) < 2 {
+		return nil, tr.Errorf("error.min_items", "waypoints", 2)
+	}
+	if len(queries) > maxRouteWaypoints {
+		return nil, tr.Errorf("error.max_items", "waypoints", maxRouteWaypoints)
+	}
+
+	speed := 80.0
+	if raw, exists := params["speed_kph"]; exists {
+		if v, ok := raw.(float64); ok {
+			speed = v
+		}
+	}
+	if speed <= 0 || speed > 1000 {
+		return nil, tr.Errorf("error.range", "speed_kph", 0, 1000)
+	}
+
+	roadFactor := 1.2
+	if raw, exists := params["road_factor"]; exists {
+		if v, ok := raw.(float64); ok {
+			roadFactor = v
+		}
+	}
+	if roadFactor < 1 || roadFactor > 3 {
+		return nil, tr.Errorf("error.range", "road_factor", 1, 3)
+	}
+
+	system, err := unitsParam(cfg, tr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Resolver coordenadas de cada punto de paso; el avance cuenta la
+	// resolución y el pronóstico de cada uno
+	progress := newProgress(ctx, 2*len(queries))
+	waypoints := make([]RouteWaypoint, len(queries))
+	for i, query := range queries {
+		wp, err := resolveWaypoint(ctx, cfg, tr, query)
+		if err != nil {
+			return nil, tr.Errorf("route.error_waypoint", i+1, query, err)
+		}
+		waypoints[i] = wp
+		progress.step(tr.T("progress.waypoint", query))
+	}
+
+	// 2. Hora de salida, interpretada en la zona horaria del origen
+	departure, err := parseDeparture(ctx, cfg, tr, stringParam(params, "departure", ""), waypoints[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Distancias acumuladas y horas estimadas de llegada
+	total := 0.0
+	for i := range waypoints {
+		if i > 0 {
+			total += gazetteer.Distance(waypoints[i-1].Lat, waypoints[i-1].Lon, waypoints[i].Lat, waypoints[i].Lon) * roadFactor
+		}
+		waypoints[i].Distance = roundTo(system.DistanceValue(total), 1)
+		eta := departure.Add(time.Duration(total / speed * float64(time.Hour)))
+		waypoints[i].ETAEpoch = eta.Unix()
+	}
+
+	// 4. Pronóstico horario en cada punto a la hora de llegada
+	fillRouteForecasts(ctx, cfg, tr, waypoints, system, progress)
+
+	result := &RouteWeatherResult{
+		Departure:     departure.Format(localTimeLayout + " MST"),
+		Units:         system,
+		Speed:         roundTo(system.SpeedValue(speed), 1),
+		RoadFactor:    roadFactor,
+		TotalDistance: roundTo(system.DistanceValue(total), 1),
+		Waypoints:     waypoints,
+	}
+	for i := 1; i < len(waypoints); i++ {
+		result.Segments = append(result.Segments, buildRouteSegment(tr, waypoints[i-1], waypoints[i]))
+	}
+
+	toolResult := &ToolResult{
+		Structured: result,
+		Document:   routeWeatherDocument(tr, result),
+	}
+	if boolParam(params, "geojson", false) {
+		if err := toolResult.attachGeoJSON(tr, "route", queries, routeGeoJSON(result)); err != nil {
+			return nil, err
+		}
+	}
+	return toolResult, nil
+}
+
+// routeGeoJSON un punto por punto de paso con el pronóstico a la hora de llegada y
+// una línea por tramo, marcada si es peligroso
+func routeGeoJSON(r *RouteWeatherResult) *GeoJSONFeatureCollection {
+	c := newFeatureCollection()
+	for i, wp := range r.Waypoints {
+		properties := map[string]interface{}{
+			"order":          i + 1,
+			"query":          wp.Query,
+			"name":           wp.Name,
+			"region":         wp.Region,
+			"country":        wp.Country,
+			"distance":       wp.Distance,
+			"eta":            wp.ETA,
+			"units":          r.Units.Name,
+			"hazards":        append([]string{}, wp.Hazards...),
+			"forecast_time":  wp.ForecastTime,
+			"condition":      wp.Condition,
+			"temperature":    wp.Temp,
+			"wind":           wp.Wind,
+			"gust":           wp.Gust,
+			"chance_of_rain": wp.ChanceOfRain,
+			"chance_of_snow": wp.ChanceOfSnow,
+			"precipitation":  wp.Precip,
+			"visibility":     wp.Visibility,
+		}
+		if wp.Error != "" {
+			properties["error"] = wp.Error
+		}
+		c.addPoint(wp.Lat, wp.Lon, properties)
+	}
+	for i, seg := range r.Segments {
+		from, to := r.Waypoints[i], r.Waypoints[i+1]
+		c.addLine(from.Lat, from.Lon, to.Lat, to.Lon, map[string]interface{}{
+			"from":      seg.From,
+			"to":        seg.To,
+			"distance":  seg.Distance,
+			"minutes":   seg.Minutes,
+			"hazardous": seg.Hazardous,
+			"hazards":   append([]string{}, seg.Hazards...),
+			"units":     r.Units.Name,
+		})
+	}
+	return c
+}
+
+// resolveWaypoint obtiene la identidad, el nombre y las coordenadas de un punto con el
+// resolver. Los nombres ambiguos no se preguntan: se usa la primera coincidencia y las
+// demás quedan en el punto. Lo que el resolver no ubica (códigos postales, aeropuertos,
+// id:, nombres sin coincidencia exacta) se busca en search.json y se usa el primer
+// resultado
+func resolveWaypoint(ctx context.Context, cfg *config.Config, tr i18n.Translator, query string) (RouteWaypoint, error) {
+	resolved, _, err := resolveLocation(ctx, cfg, tr, query, false)
+	if err != nil {
+		return RouteWaypoint{}, err
+	}
+	if resolved != nil && resolved.located {
+		wp := RouteWaypoint{
+			LocationIdentity: resolved.LocationIdentity,
+			Query:            query,
+			Name:             resolved.Name,
+			Region:           resolved.Region,
+			Country:          resolved.Country,
+			Lat:              resolved.Lat,
+			Lon:              resolved.Lon,
+			Alternatives:     resolved.Alternatives,
+		}
+		if wp.Name == "" {
+			wp.Name = query
+		}
+		return wp, nil
+	}
+
+	wp := RouteWaypoint{Query: query}
+	if resolved != nil {
+		wp.LocationIdentity = resolved.LocationIdentity
+		query = resolved.Ref
+	}
+	results, err := fetchSearch(ctx, cfg, tr, query)
+	if err != nil {
+		return RouteWaypoint{}, err
+	}
+	if len(results) == 0 {
+		return RouteWaypoint{}, tr.Errorf("route.error_not_found")
+	}
+	best := results[0]
+	wp.Name, wp.Region, wp.Country = best.Name, best.Region, best.Country
+	wp.Lat, wp.Lon = best.Lat, best.Lon
+	return wp, nil
+}
+
+// parseDeparture interpreta la hora de salida (RFC3339 o "YYYY-MM-DD HH:MM" en hora local del origen)
+func parseDeparture(ctx context.Context, cfg *config.Config, tr i18n.Translator, value string, origin RouteWaypoint) (time.Time, error) {
+	if value == "" {
+		return time.Now().Truncate(time.Minute), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	// La zona horaria del origen se obtiene de WeatherAPI
+	loc := time.UTC
+	if current, err := fetchCurrent(ctx, cfg, tr, formatLatLon(origin.Lat, origin.Lon)); err == nil {
+		if tz, err := time.LoadLocation(current.Location.TzID); err == nil {
+			loc = tz
+		}
+	}
+
+	t, err := time.ParseInLocation(localTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}, tr.Errorf("route.error_departure")
+	}
+	return t, nil
+}
+
+// fillRouteForecasts completa en paralelo las condiciones previstas de cada punto de paso
+func fillRouteForecasts(ctx context.Context, cfg *config.Config, tr i18n.Translator, waypoints []RouteWaypoint, system units.System, progress *progressCounter) {
+	sem := make(chan struct{}, maxCompareConcurrency)
+	var wg sync.WaitGroup
+
+	for i := range waypoints {
+		wg.Add(1)
+		go func(wp *RouteWaypoint) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			fillWaypointForecast(ctx, cfg, tr, wp, system)
+			progress.step(tr.T("progress.location", wp.Query))
+		}(&waypoints[i])
+	}
+
+	wg.Wait()
+}
+
+// fillWaypointForecast busca la hora del pronóstico que corresponde a la llegada al punto
+func fillWaypointForecast(ctx context.Context, cfg *config.Config, tr i18n.Translator, wp *RouteWaypoint, system units.System) {
+	days := int(time.Until(time.Unix(wp.ETAEpoch, 0)).Hours()/24) + 2
+	if days > 10 {
+		wp.Error = tr.T("route.error_horizon")
+		return
+	}
+	if days < 1 {
+		days = 1
+	}
+
+	forecast, err := fetchForecast(ctx, cfg, tr, formatLatLon(wp.Lat, wp.Lon), days)
+	if err != nil {
+		wp.Error = err.Error()
+		return
+	}
+
+	// Los puntos dados como coordenadas toman el nombre del lugar más cercano
+	if wp.Country == "" {
+		wp.Name = forecast.Location.Name
+		wp.Region = forecast.Location.Region
+		wp.Country = forecast.Location.Country
+	}
+
+	if tz, err := time.LoadLocation(forecast.Location.TzID); err == nil {
+		wp.ETA = time.Unix(wp.ETAEpoch, 0).In(tz).Format(localTimeLayout + " MST")
+	} else {
+		wp.ETA = time.Unix(wp.ETAEpoch, 0).UTC().Format(localTimeLayout + " UTC")
+	}
+
+	hour, ok := hourAt(forecast, wp.ETAEpoch)
+	if !ok {
+		wp.Error = tr.T("route.error_no_hour")
+		return
+	}
+
+	wp.ForecastTime = hour.Time
+	wp.Condition = hour.Condition.Text
+	wp.Temp = roundTo(system.Temp(hour.TempC), 1)
+	wp.Wind = roundTo(system.SpeedValue(hour.WindKph), 1)
+	wp.Gust = roundTo(system.SpeedValue(hour.GustKph), 1)
+	wp.ChanceOfRain = numberValue(hour.ChanceOfRain)
+	wp.ChanceOfSnow = numberValue(hour.ChanceOfSnow)
+	wp.Precip = roundTo(system.PrecipValue(hour.PrecipMm), 2)
+	wp.Visibility = roundTo(system.DistanceValue(hour.VisKm), 1)
+	wp.Hazards = hourHazards(tr, hour, system)
+}
+
+// hourAt devuelve la hora del pronóstico que contiene el instante epoch
+func hourAt(forecast *models.ForecastResponse, epoch int64) (models.HourInfo, bool) {
+	for _, day := range forecast.Forecast.Forecastday {
+		for _, hour := range day.Hour {
+			if epoch >= hour.TimeEpoch && epoch < hour.TimeEpoch+3600 {
+				return hour, true
+			}
+		}
+	}
+	return models.HourInfo{}, false
+}
+
+// hourHazards detecta condiciones peligrosas para circular en una hora del pronóstico
+func hourHazards(tr i18n.Translator, h models.HourInfo, u units.System) []string {
+	var hazards []string
+
+	switch h.Condition.Code {
+	case 1087, 1273, 1276, 1279, 1282:
+		hazards = append(hazards, tr.T("route.hazard_thunder"))
+	}
+	if h.WindKph >= 50 || h.GustKph >= 70 {
+		hazards = append(hazards, tr.T("route.hazard_wind", u.FormatSpeed(h.WindKph), u.FormatSpeed(h.GustKph)))
+	}
+	if h.PrecipMm >= 4 {
+		hazards = append(hazards, tr.T("route.hazard_rain", u.FormatPrecip(h.PrecipMm)))
+	}
+	if h.WillItSnow == 1 || numberValue(h.ChanceOfSnow) >= 40 {
+		hazards = append(hazards, tr.T("route.hazard_snow"))
+	}
+	if h.TempC <= 1 && (h.PrecipMm > 0 || h.Humidity >= 90) {
+		hazards = append(hazards, tr.T("route.hazard_ice", u.FormatTemp(h.TempC)))
+	}
+	if h.VisKm < 1 {
+		hazards = append(hazards, tr.T("route.hazard_visibility", u.FormatDistance(h.VisKm)))
+	}
+
+	return hazards
+}
+
+// buildRouteSegment arma un tramo y lo marca peligroso si alguno de sus extremos lo es
+func buildRouteSegment(tr i18n.Translator, from, to RouteWaypoint) RouteSegment {
+	segment := RouteSegment{
+		From:     from.Name,
+		To:       to.Name,
+		Distance: roundTo(to.Distance-from.Distance, 1),
+		Minutes:  int((to.ETAEpoch - from.ETAEpoch) / 60),
+	}
+
+	seen := map[string]bool{}
+	for _, wp := range []RouteWaypoint{from, to} {
+		for _, hazard := range wp.Hazards {
+			label := tr.T("route.hazard_at", hazard, wp.Name)
+			if !seen[label] {
+				seen[label] = true
+				segment.Hazards = append(segment.Hazards, label)
+			}
+		}
+	}
+	segment.Hazardous = len(segment.Hazards) > 0
+
+	return segment
+}
+
+// routeWeatherDocument arma la presentación de get_route_weather; cada punto de
+// paso es un elemento de la lista con el pronóstico y los peligros en líneas aparte
+func routeWeatherDocument(tr i18n.Translator, r *RouteWeatherResult) *render.Document {
+	u := r.Units
+	doc := &render.Document{
+		Icon:  "🚚",
+		Title: tr.T("route.title"),
+		Fields: []render.Field{
+			{Icon: "🕐", Label: tr.T("label.departure"), Value: r.Departure},
+			{Icon: "📏", Label: tr.T("label.distance"), Value: tr.T("route.distance", r.TotalDistance, u.Distance, r.Speed, u.Speed, r.RoadFactor)},
+		},
+	}
+
+	var waypoints []string
+	for _, wp := range r.Waypoints {
+		if len(wp.Alternatives) > 0 {
+			label := placeLabel(PlaceData{Name: wp.Name, Region: wp.Region, Country: wp.Country})
+			doc.Notes = append(doc.Notes, alternativesNote(tr, label, wp.Alternatives))
+		}
+		item := wp.Name
+		if wp.Country != "" {
+			item += ", " + wp.Country
+		}
+		item += fmt.Sprintf(" (%s %.1f)", u.Distance, wp.Distance)
+
+		if wp.Error != "" {
+			waypoints = append(waypoints, item+"\n"+wp.Error)
+			continue
+		}
+
+		item += "\n" + tr.T("route.eta", wp.ETA)
+		item += "\n" + tr.T("route.waypoint", wp.Condition, wp.Temp, u.Temperature, wp.Wind, u.Speed, wp.Gust,
+			wp.ChanceOfRain, wp.Precip, u.Precip, wp.Visibility, u.Distance)
+		for _, hazard := range wp.Hazards {
+			item += "\n" + tr.T("route.hazard", hazard)
+		}
+		waypoints = append(waypoints, item)
+	}
+	doc.Sections = []render.Section{{Icon: "📍", Title: tr.T("route.waypoints"), List: waypoints, Ordered: true}}
+
+	var hazards []string
+	for _, segment := range r.Segments {
+		if segment.Hazardous {
+			hazards = append(hazards, fmt.Sprintf("%s → %s (%.1f %s): %s", segment.From, segment.To, segment.Distance, u.Distance, strings.Join(segment.Hazards, "; ")))
+		}
+	}
+
+	if len(hazards) == 0 {
+		doc.Summary = tr.T("route.summary", len(r.Waypoints), r.TotalDistance, u.Distance, tr.T("route.no_hazards"))
+		doc.Notes = append(doc.Notes, render.Field{Icon: "✅", Value: tr.T("route.no_hazards")})
+		return doc
+	}
+
+	doc.Summary = tr.T("route.summary", len(r.Waypoints), r.TotalDistance, u.Distance, strings.Join(hazards, "; "))
+	doc.Sections = append(doc.Sections, render.Section{
+		Icon:  "🚨",
+		Title: tr.T("route.hazardous", len(hazards)),
+		List:  hazards,
+	})
+	return doc
+}
+
+// parseLatLon interpreta textos "lat,lon"
+func parseLatLon(value string) (float64, float64, bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, false
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// formatLatLon arma la consulta "lat,lon" que acepta WeatherAPI
+func formatLatLon(lat, lon float64) string {
+	return fmt.Sprintf("%.4f,%.4f", lat, lon)
+}
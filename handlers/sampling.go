@@ -0,0 +1,47 @@
+package handlers
+
+import "context"
+
+// SamplingMessage mensaje de una solicitud sampling/createMessage
+type SamplingMessage struct {
+	Role    string                 `json:"role"`
+	Content map[string]interface{} `json:"content"`
+}
+
+// SamplingRequest parámetros de sampling/createMessage
+type SamplingRequest struct {
+	Messages         []SamplingMessage      `json:"messages"`
+	SystemPrompt     string                 `json:"systemPrompt,omitempty"`
+	IncludeContext   string                 `json:"includeContext,omitempty"`
+	MaxTokens        int                    `json:"maxTokens"`
+	ModelPreferences map[string]interface{} `json:"modelPreferences,omitempty"`
+}
+
+// SamplingResult respuesta del cliente a sampling/createMessage
+type SamplingResult struct {
+	Role    string `json:"role"`
+	Content struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Model      string `json:"model"`
+	StopReason string `json:"stopReason"`
+}
+
+// Sampler envía sampling/createMessage al cliente y espera su respuesta
+type Sampler func(ctx context.Context, request SamplingRequest) (*SamplingResult, error)
+
+// samplerKey clave del Sampler en el contexto
+type samplerKey struct{}
+
+// WithSampler devuelve un contexto con el que las herramientas pueden pedir texto al
+// modelo del cliente; solo se usa si el cliente anunció la capacidad sampling
+func WithSampler(ctx context.Context, sampler Sampler) context.Context {
+	return context.WithValue(ctx, samplerKey{}, sampler)
+}
+
+// samplerFrom devuelve el Sampler del contexto; nil si el cliente no soporta sampling
+func samplerFrom(ctx context.Context) Sampler {
+	sampler, _ := ctx.Value(samplerKey{}).(Sampler)
+	return sampler
+}
@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+	"weather-mcp-server/render"
+	"weather-mcp-server/units"
+)
+
+// localTimeLayout formato de hora local que usa WeatherAPI ("2006-01-02 15:04")
+const localTimeLayout = "2006-01-02 15:04"
+
+// HourScore puntuación de una hora del pronóstico para una actividad
+type HourScore struct {
+	Time      string   `json:"time"`
+	Score     int      `json:"score"`
+	Condition string   `json:"condition"`
+	Temp      float64  `json:"temperature"`
+	Reasons   []string `json:"reasons,omitempty"`
+}
+
+// ActivityWindow ventana de horas consecutivas recomendada
+type ActivityWindow struct {
+	Start   string   `json:"start"`
+	End     string   `json:"end"`
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// ActivityScoreResult resultado estructurado de score_activity
+type ActivityScoreResult struct {
+	Activity string           `json:"activity"`
+	Location PlaceData        `json:"location"`
+	Units    units.System     `json:"units"`
+	Duration int              `json:"duration_hours"`
+	MinScore int              `json:"min_score"`
+	Windows  []ActivityWindow `json:"windows"`
+	Hours    []HourScore      `json:"hours"`
+}
+
+// ScoreActivity puntúa cada hora del pronóstico según el perfil de una actividad
+// y devuelve las mejores ventanas de tiempo
+func ScoreActivity(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error) {
+	tr, err := langParam(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := requiredString(tr, params, "location")
+	if err != nil {
+		return nil, err
+	}
+
+	activity, ok := params["activity"].(string)
+	if !ok || activity == "" {
+		return nil, tr.Errorf("score.error_required", strings.Join(cfg.ActivityNames(), ", "))
+	}
+	profile, exists := cfg.ActivityProfiles[activity]
+	if !exists {
+		return nil, tr.Errorf("score.error_unknown", activity, strings.Join(cfg.ActivityNames(), ", "))
+	}
+
+	start, err := parseWindowBound(tr, stringParam(params, "start", ""), false)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseWindowBound(tr, stringParam(params, "end", ""), true)
+	if err != nil {
+		return nil, err
+	}
+	if start != "" && end != "" && end <= start {
+		return nil, tr.Errorf("score.error_window")
+	}
+
+	duration := intParam(params, "duration", 1)
+	if duration < 1 || duration > 12 {
+		return nil, tr.Errorf("error.range", "duration", 1, 12)
+	}
+	minScore := intParam(params, "min_score", 60)
+	if minScore < 0 || minScore > 100 {
+		return nil, tr.Errorf("error.range", "min_score", 0, 100)
+	}
+	system, err := unitsParam(cfg, tr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := intParam(params, "limit", 3)
+	if limit < 1 {
+		limit = 3
+	}
+
+	chartFormat, err := chartParam(tr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los días de pronóstico se cuentan desde la fecha local de la ubicación, que se
+	// conoce con la respuesta: se estima con la fecha UTC (que puede ir un día
+	// adelantada) y, si faltan días para llegar a end, se vuelve a pedir
+	days := forecastDaysUntil(end, time.Now().UTC(), 2)
+	forecast, err := fetchForecast(ctx, cfg, tr, location, days)
+	if err != nil {
+		return nil, err
+	}
+	if today, err := time.Parse(localTimeLayout, forecast.Location.Localtime); err == nil {
+		if needed := forecastDaysUntil(end, today, 2); needed > days {
+			if forecast, err = fetchForecast(ctx, cfg, tr, location, needed); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Por defecto la ventana son las próximas 24 horas en hora local
+	if start == "" {
+		start = truncateToHour(forecast.Location.Localtime)
+	}
+	if end == "" {
+		if t, err := time.Parse(localTimeLayout, start); err == nil {
+			end = t.Add(24 * time.Hour).Format(localTimeLayout)
+		}
+	}
+
+	var hours []HourScore
+	var window []models.HourInfo
+	for _, day := range forecast.Forecast.Forecastday {
+		for _, hour := range day.Hour {
+			if hour.Time < start || (end != "" && hour.Time >= end) {
+				continue
+			}
+			hours = append(hours, scoreHour(tr, profile, hour, system))
+			window = append(window, hour)
+		}
+	}
+	if len(hours) == 0 {
+		return nil, tr.Errorf("score.error_no_hours")
+	}
+
+	result := &ActivityScoreResult{
+		Activity: activity,
+		Location: placeData(ctx, forecast.Location),
+		Units:    system,
+		Duration: duration,
+		MinScore: minScore,
+		Windows:  bestWindows(hours, duration, minScore, limit),
+		Hours:    hours,
+	}
+
+	toolResult := &ToolResult{
+		Structured: result,
+		Document:   activityScoreDocument(tr, result, profile),
+	}
+
+	// Gráfico opcional de las horas puntuadas
+	if chartFormat != "" {
+		if err := toolResult.attachChart(chartFormat, hourlyChart(tr, forecast.Location.Name, window, system)); err != nil {
+			return nil, err
+		}
+	}
+
+	return toolResult, nil
+}
+
+// scoreHour calcula la puntuación (0-100) de una hora y los motivos de las penalizaciones
+func scoreHour(tr i18n.Translator, p config.ActivityProfile, h models.HourInfo, u units.System) HourScore {
+	score := 100.0
+	var reasons []string
+	veto := false
+
+	penalize := func(points float64, reason string) {
+		score -= points
+		reasons = append(reasons, reason)
+	}
+	reject := func(reason string) {
+		veto = true
+		reasons = append(reasons, reason)
+	}
+
+	// Temperatura: fuera de límites descarta, fuera del rango ideal penaliza
+	switch {
+	case h.TempC < p.MinTempC:
+		reject(tr.T("score.temp_below", u.FormatTemp(h.TempC), u.FormatTemp(p.MinTempC)))
+	case h.TempC > p.MaxTempC:
+		reject(tr.T("score.temp_above", u.FormatTemp(h.TempC), u.FormatTemp(p.MaxTempC)))
+	case h.TempC < p.IdealMinTempC:
+		penalize(minFloat(40, (p.IdealMinTempC-h.TempC)*5), tr.T("score.cool", u.FormatTemp(h.TempC)))
+	case h.TempC > p.IdealMaxTempC:
+		penalize(minFloat(40, (h.TempC-p.IdealMaxTempC)*5), tr.T("score.hot", u.FormatTemp(h.TempC)))
+	}
+
+	// Viento y ráfagas
+	if h.WindKph > p.MaxWindKph {
+		reject(tr.T("score.wind_above", u.FormatSpeed(h.WindKph), u.FormatSpeed(p.MaxWindKph)))
+	} else if h.WindKph > p.MaxWindKph*0.6 {
+		penalize(20*h.WindKph/p.MaxWindKph, tr.T("score.wind_moderate", u.FormatSpeed(h.WindKph)))
+	}
+	if h.GustKph > p.MaxGustKph {
+		reject(tr.T("score.gust_above", u.FormatSpeed(h.GustKph), u.FormatSpeed(p.MaxGustKph)))
+	}
+
+	// Precipitación
+	chance := numberValue(h.ChanceOfRain)
+	if snow := numberValue(h.ChanceOfSnow); snow > chance {
+		chance = snow
+	}
+	if chance > p.MaxPrecipChance {
+		reject(tr.T("score.chance_above", chance, p.MaxPrecipChance))
+	} else if chance > p.MaxPrecipChance/2 {
+		penalize(25*chance/p.MaxPrecipChance, tr.T("score.chance", chance))
+	}
+	if h.PrecipMm > p.MaxPrecipMm {
+		reject(tr.T("score.precip_above", u.FormatPrecip(h.PrecipMm), u.FormatPrecip(p.MaxPrecipMm)))
+	}
+
+	// Índice UV
+	if h.UV > p.MaxUV {
+		penalize(minFloat(30, (h.UV-p.MaxUV)*10), tr.T("score.uv_high", h.UV))
+	}
+
+	// Visibilidad y luz del día
+	if h.VisKm < p.MinVisibilityKm {
+		reject(tr.T("score.visibility_below", u.FormatDistance(h.VisKm), u.FormatDistance(p.MinVisibilityKm)))
+	}
+	if p.DaylightOnly && h.IsDay == 0 {
+		reject(tr.T("score.night"))
+	}
+
+	if veto || score < 0 {
+		score = 0
+	}
+
+	return HourScore{
+		Time:      h.Time,
+		Score:     int(score + 0.5),
+		Condition: h.Condition.Text,
+		Temp:      roundTo(u.Temp(h.TempC), 1),
+		Reasons:   reasons,
+	}
+}
+
+// bestWindows busca las mejores ventanas de horas consecutivas que no se superpongan
+func bestWindows(hours []HourScore, duration int, minScore int, limit int) []ActivityWindow {
+	type candidate struct {
+		start int
+		avg   float64
+	}
+
+	var candidates []candidate
+	for i := 0; i+duration <= len(hours); i++ {
+		total := 0
+		ok := true
+		for _, h := range hours[i : i+duration] {
+			if h.Score < minScore {
+				ok = false
+				break
+			}
+			total += h.Score
+		}
+		if ok {
+			candidates = append(candidates, candidate{start: i, avg: float64(total) / float64(duration)})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].avg > candidates[j].avg
+	})
+
+	used := make([]bool, len(hours))
+	windows := []ActivityWindow{}
+	for _, c := range candidates {
+		if len(windows) == limit {
+			break
+		}
+		overlaps := false
+		for i := c.start; i < c.start+duration; i++ {
+			if used[i] {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+
+		reasonSet := map[string]bool{}
+		var reasons []string
+		for i := c.start; i < c.start+duration; i++ {
+			used[i] = true
+			for _, r := range hours[i].Reasons {
+				if !reasonSet[r] {
+					reasonSet[r] = true
+					reasons = append(reasons, r)
+				}
+			}
+		}
+
+		window := ActivityWindow{
+			Start:   hours[c.start].Time,
+			End:     hours[c.start+duration-1].Time,
+			Score:   int(c.avg + 0.5),
+			Reasons: reasons,
+		}
+		if t, err := time.Parse(localTimeLayout, window.End); err == nil {
+			window.End = t.Add(time.Hour).Format(localTimeLayout)
+		}
+		windows = append(windows, window)
+	}
+
+	return windows
+}
+
+// activityScoreDocument arma la presentación de score_activity
+func activityScoreDocument(tr i18n.Translator, r *ActivityScoreResult, p config.ActivityProfile) *render.Document {
+	description, ok := tr.Lookup("activity." + r.Activity)
+	if !ok {
+		description = p.Description
+	}
+
+	doc := &render.Document{
+		Icon:  "🏃",
+		Title: tr.T("score.title"),
+		Fields: []render.Field{
+			{Icon: "🎯", Label: tr.T("label.activity"), Value: fmt.Sprintf("%s (%s)", r.Activity, description)},
+			{Icon: "📍", Label: tr.T("label.location"), Value: placeLabel(r.Location)},
+			{Icon: "⏱️", Label: tr.T("label.duration"), Value: tr.T("score.duration", r.Duration, r.MinScore)},
+		},
+	}
+
+	if len(r.Windows) == 0 {
+		doc.Summary = tr.T("score.no_windows")
+		doc.Sections = append(doc.Sections, render.Section{Fields: []render.Field{{Icon: "❌", Value: doc.Summary}}})
+	} else {
+		var windows []string
+		for _, w := range r.Windows {
+			item := tr.T("score.window", w.Start, w.End, w.Score)
+			if len(w.Reasons) > 0 {
+				item += "\n" + strings.Join(w.Reasons, "; ")
+			}
+			windows = append(windows, item)
+		}
+		best := r.Windows[0]
+		doc.Summary = tr.T("score.summary", r.Activity, placeLabel(r.Location), best.Start, best.End, best.Score)
+		doc.Sections = append(doc.Sections, render.Section{
+			Icon:    "✅",
+			Title:   tr.T("score.windows"),
+			List:    windows,
+			Ordered: true,
+		})
+	}
+
+	var hours []string
+	for _, h := range r.Hours {
+		line := fmt.Sprintf("%s %3d/100 %.1f%s %s", h.Time, h.Score, h.Temp, r.Units.Temperature, h.Condition)
+		if len(h.Reasons) > 0 {
+			line += " — " + strings.Join(h.Reasons, "; ")
+		}
+		hours = append(hours, line)
+	}
+	doc.Sections = append(doc.Sections, render.Section{
+		Icon:  "📋",
+		Title: tr.T("score.hours"),
+		List:  hours,
+	})
+
+	return doc
+}
+
+// parseWindowBound valida un límite de ventana "YYYY-MM-DD" o "YYYY-MM-DD HH:MM";
+// una fecha sola como fin incluye el día completo
+func parseWindowBound(tr i18n.Translator, value string, isEnd bool) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(localTimeLayout, value); err == nil {
+		return t.Format(localTimeLayout), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return "", tr.Errorf("score.error_date", value)
+	}
+	if isEnd {
+		t = t.Add(24 * time.Hour)
+	}
+	return t.Format(localTimeLayout), nil
+}
+
+// forecastDaysUntil calcula cuántos días de pronóstico, contando desde la fecha de now
+// (la hora local de la ubicación), se necesitan para cubrir end
+func forecastDaysUntil(end string, now time.Time, def int) int {
+	if end == "" {
+		return def
+	}
+	t, err := time.Parse(localTimeLayout, end)
+	if err != nil {
+		return def
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(t.Sub(today).Hours()/24) + 1
+	if days < 1 {
+		return 1
+	}
+	if days > 10 {
+		return 10
+	}
+	return days
+}
+
+// truncateToHour convierte una hora local de WeatherAPI a la hora en punto
+func truncateToHour(localtime string) string {
+	t, err := time.Parse(localTimeLayout, localtime)
+	if err != nil {
+		return ""
+	}
+	return t.Truncate(time.Hour).Format(localTimeLayout)
+}
+
+// minFloat devuelve el menor de dos valores
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"weather-mcp-server/i18n"
+)
+
+func TestForecastDaysUntil(t *testing.T) {
+	// Hora local de la ubicación como la devuelve WeatherAPI (sin zona)
+	local := func(s string) time.Time {
+		t, _ := time.Parse(localTimeLayout, s)
+		return t
+	}
+	tests := []struct {
+		end  string
+		now  time.Time
+		want int
+	}{
+		{"", local("2026-10-19 10:00"), 2},
+		{"2026-10-19 18:00", local("2026-10-19 10:00"), 1},
+		{"2026-10-20 00:00", local("2026-10-19 23:30"), 2},
+		{"2026-10-21 12:00", local("2026-10-19 00:15"), 3},
+		// En la ubicación todavía es el 18 aunque en UTC ya sea el 19: hace falta un
+		// día más que contando desde la fecha UTC
+		{"2026-10-20 12:00", local("2026-10-18 14:00"), 3},
+		{"2026-10-01 12:00", local("2026-10-19 10:00"), 1},
+		{"2026-12-31 12:00", local("2026-10-19 10:00"), 10},
+		{"mañana", local("2026-10-19 10:00"), 2},
+	}
+	for _, tt := range tests {
+		if got := forecastDaysUntil(tt.end, tt.now, 2); got != tt.want {
+			t.Errorf("forecastDaysUntil(%q, %s) = %d, se esperaba %d", tt.end, tt.now.Format(localTimeLayout), got, tt.want)
+		}
+	}
+}
+
+func TestParseWindowBound(t *testing.T) {
+	tr := i18n.For("es")
+	tests := []struct {
+		value string
+		isEnd bool
+		want  string
+	}{
+		{"", false, ""},
+		{"2026-10-19", false, "2026-10-19 00:00"},
+		{"2026-10-19", true, "2026-10-20 00:00"},
+		{"2026-10-19 7:30", false, "2026-10-19 07:30"},
+	}
+	for _, tt := range tests {
+		got, err := parseWindowBound(tr, tt.value, tt.isEnd)
+		if err != nil || got != tt.want {
+			t.Errorf("parseWindowBound(%q, %v) = %q, %v; se esperaba %q", tt.value, tt.isEnd, got, err, tt.want)
+		}
+	}
+	if _, err := parseWindowBound(tr, "19/10/2026", false); err == nil {
+		t.Errorf("parseWindowBound(19/10/2026): se esperaba un error")
+	}
+}
+
+func TestBestWindows(t *testing.T) {
+	scores := []int{50, 80, 90, 70, 40, 95, 85}
+	var hours []HourScore
+	for i, score := range scores {
+		hours = append(hours, HourScore{Time: time.Date(2026, 10, 19, i, 0, 0, 0, time.UTC).Format(localTimeLayout), Score: score})
+	}
+
+	windows := bestWindows(hours, 2, 60, 3)
+	want := []struct {
+		start string
+		score int
+	}{
+		{"2026-10-19 05:00", 90},
+		{"2026-10-19 01:00", 85},
+	}
+	if len(windows) != len(want) {
+		t.Fatalf("bestWindows = %+v, se esperaban %d ventanas", windows, len(want))
+	}
+	for i, w := range want {
+		if windows[i].Start != w.start || windows[i].Score != w.score {
+			t.Errorf("ventana %d = %s (%d), se esperaba %s (%d)", i, windows[i].Start, windows[i].Score, w.start, w.score)
+		}
+	}
+
+	if windows := bestWindows(hours, 3, 96, 3); len(windows) != 0 {
+		t.Errorf("con min_score 96 no debería haber ventanas: %+v", windows)
+	}
+}
@@ -1,76 +1,141 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"net/url"
+	"strings"
 
 	"weather-mcp-server/config"
+	"weather-mcp-server/gazetteer"
+	"weather-mcp-server/i18n"
 	"weather-mcp-server/models"
+	"weather-mcp-server/render"
 )
 
-// SearchLocations busca ubicaciones por nombre
-func SearchLocations(cfg *config.Config, params map[string]interface{}) (interface{}, error) {
-	query, ok := params["query"].(string)
-	if !ok || query == "" {
-		return nil, fmt.Errorf("parámetro 'query' es requerido")
-	}
+// SearchResult resultado estructurado de search_locations
+type SearchResult struct {
+	Query   string                        `json:"query"`
+	Country string                        `json:"country,omitempty"`
+	Region  string                        `json:"region,omitempty"`
+	Results []models.LocationSearchResult `json:"results"`
+	// Source origen de los resultados: "weatherapi" o "gazetteer" (sin ID de WeatherAPI)
+	Source string `json:"source"`
+	// Offline si los resultados son del gazetteer porque WeatherAPI no respondió
+	Offline bool `json:"offline,omitempty"`
+}
 
-	// Construir URL
-	baseURL := fmt.Sprintf("%s/search.json", cfg.BaseURL)
-	params_url := url.Values{}
-	params_url.Add("key", cfg.WeatherAPIKey)
-	params_url.Add("q", query)
+// SearchLocations busca ubicaciones por nombre (o la más cercana a unas coordenadas),
+// opcionalmente de un país y una región
+func SearchLocations(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error) {
+	tr, err := langParam(cfg, params)
+	if err != nil {
+		return nil, err
+	}
 
-	fullURL := fmt.Sprintf("%s?%s", baseURL, params_url.Encode())
+	query, err := requiredString(tr, params, "query")
+	if err != nil {
+		return nil, err
+	}
 
-	// Hacer request
-	resp, err := http.Get(fullURL)
+	filter := gazetteer.Filter{
+		Country: stringParam(params, "country", ""),
+		Region:  stringParam(params, "region", ""),
+	}
+	searchResp, source, err := searchLocations(ctx, cfg, tr, query, filter)
 	if err != nil {
-		return nil, fmt.Errorf("error conectando con WeatherAPI: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error de WeatherAPI (código %d): verificar consulta y API key", resp.StatusCode)
+	result := &SearchResult{
+		Query:   query,
+		Country: filter.Country,
+		Region:  filter.Region,
+		Results: searchResp,
+		Source:  source,
+		Offline: source == searchSourceGazetteer && cfg.GazetteerMode != config.GazetteerFirst,
+	}
+	if result.Results == nil {
+		result.Results = []models.LocationSearchResult{}
 	}
 
-	// Decodificar respuesta
-	var searchResp models.SearchLocationResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return nil, fmt.Errorf("error decodificando respuesta: %v", err)
+	toolResult := &ToolResult{
+		Structured: result,
+		Document:   searchDocument(tr, result),
+	}
+	if boolParam(params, "geojson", false) {
+		if err := toolResult.attachGeoJSON(tr, "search", []string{query}, searchGeoJSON(result)); err != nil {
+			return nil, err
+		}
 	}
+	return toolResult, nil
+}
 
-	if len(searchResp) == 0 {
-		return "❌ No se encontraron ubicaciones para la consulta: " + query, nil
+// searchGeoJSON un punto por ubicación encontrada
+func searchGeoJSON(r *SearchResult) *GeoJSONFeatureCollection {
+	c := newFeatureCollection()
+	for _, loc := range r.Results {
+		c.addPoint(loc.Lat, loc.Lon, map[string]interface{}{
+			"id":      loc.ID,
+			"name":    loc.Name,
+			"region":  loc.Region,
+			"country": loc.Country,
+			"url":     loc.URL,
+		})
 	}
+	return c
+}
 
-	// Formatear respuesta legible
-	result := fmt.Sprintf(`🔍 BÚSQUEDA DE UBICACIONES
-📝 Consulta: "%s"
-📍 Resultados encontrados: %d
+// searchDocument arma la presentación de los resultados de búsqueda
+func searchDocument(tr i18n.Translator, r *SearchResult) *render.Document {
+	doc := &render.Document{
+		Icon:  "🔍",
+		Title: tr.T("search.title"),
+		Fields: []render.Field{
+			{Icon: "📝", Label: tr.T("label.query"), Value: fmt.Sprintf("%q", r.Query)},
+		},
+	}
+	if r.Country != "" {
+		doc.Fields = append(doc.Fields, render.Field{Icon: "🌍", Label: tr.T("label.country"), Value: r.Country})
+	}
+	if r.Region != "" {
+		doc.Fields = append(doc.Fields, render.Field{Icon: "🗺️", Label: tr.T("label.region"), Value: r.Region})
+	}
+	doc.Fields = append(doc.Fields, render.Field{Icon: "📍", Label: tr.T("search.count"), Value: fmt.Sprintf("%d", len(r.Results))})
 
-`, query, len(searchResp))
+	var sourceNote []render.Field
+	switch {
+	case r.Offline:
+		sourceNote = []render.Field{{Icon: "📴", Value: tr.T("search.offline")}}
+	case r.Source == searchSourceGazetteer:
+		sourceNote = []render.Field{{Icon: "📚", Value: tr.T("search.gazetteer")}}
+	}
 
-	for i, location := range searchResp {
-		result += fmt.Sprintf(`%d. %s
-   📍 Región: %s, %s
-   🌐 Coordenadas: %.2f, %.2f
-   🔗 ID: %d
+	if len(r.Results) == 0 {
+		doc.Summary = tr.T("search.none", r.Query)
+		doc.Notes = append([]render.Field{{Icon: "❌", Value: doc.Summary}}, sourceNote...)
+		return doc
+	}
 
-`,
-			i+1,
+	table := &render.Table{Columns: []string{"#", tr.T("label.name"), tr.T("label.region"), tr.T("label.country"), tr.T("label.coordinates"), "ID"}}
+	var names []string
+	for i, location := range r.Results {
+		id := "-"
+		if location.ID > 0 {
+			id = fmt.Sprintf("%d", location.ID)
+		}
+		table.Rows = append(table.Rows, []string{
+			fmt.Sprintf("%d", i+1),
 			location.Name,
 			location.Region,
 			location.Country,
-			location.Lat,
-			location.Lon,
-			location.ID,
-		)
+			fmt.Sprintf("%.2f, %.2f", location.Lat, location.Lon),
+			id,
+		})
+		names = append(names, placeLabel(PlaceData{Name: location.Name, Region: location.Region, Country: location.Country}))
 	}
 
-	result += "💡 Tip: Puedes usar cualquiera de estos nombres en las otras herramientas del clima."
-
-	return result, nil
+	doc.Sections = []render.Section{{Table: table}}
+	doc.Notes = append(sourceNote, render.Field{Icon: "💡", Value: tr.T("search.tip")})
+	doc.Summary = tr.T("search.summary", r.Query, strings.Join(names, "; "))
+	return doc
 }
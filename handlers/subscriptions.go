@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+)
+
+// Subscriptions suscripciones a recursos por sesión y sondeo en segundo plano de las
+// ubicaciones suscritas. notify recibe la sesión y la URI suscrita cada vez que el
+// clima cambia de forma significativa
+type Subscriptions struct {
+	cfg    *config.Config
+	notify func(session, uri string)
+
+	mu      sync.Mutex
+	watches map[string]*watch // por URI
+}
+
+// watch estado de una URI suscrita
+type watch struct {
+	location string
+	sessions map[string]bool
+	// last estado con el que se compara el próximo sondeo; nil hasta el primero
+	last *weatherSnapshot
+}
+
+// weatherSnapshot datos del sondeo que se comparan entre vueltas
+type weatherSnapshot struct {
+	TempC  float64
+	Code   int
+	Alerts map[string]bool // titulares de las alertas vigentes
+}
+
+// NewSubscriptions crea el registro de suscripciones; el sondeo empieza con Run
+func NewSubscriptions(cfg *config.Config, notify func(session, uri string)) *Subscriptions {
+	return &Subscriptions{
+		cfg:     cfg,
+		notify:  notify,
+		watches: map[string]*watch{},
+	}
+}
+
+// Subscribe suscribe una sesión a una URI weather://current/{location}. La primera
+// suscripción a una URI toma el estado inicial en segundo plano
+func (s *Subscriptions) Subscribe(tr i18n.Translator, session, uri string) error {
+	host, segments, _, err := parseResourceURI(uri)
+	if err != nil {
+		return err
+	}
+	if host != "current" || len(segments) != 1 {
+		return tr.Errorf("resource.error_subscribe", uri)
+	}
+
+	s.mu.Lock()
+	w, exists := s.watches[uri]
+	if !exists {
+		w = &watch{location: segments[0], sessions: map[string]bool{}}
+		s.watches[uri] = w
+	}
+	w.sessions[session] = true
+	s.mu.Unlock()
+
+	if !exists {
+		go s.check(uri)
+	}
+	return nil
+}
+
+// Unsubscribe cancela la suscripción de una sesión a una URI
+func (s *Subscriptions) Unsubscribe(session, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w, ok := s.watches[uri]; ok {
+		delete(w.sessions, session)
+		if len(w.sessions) == 0 {
+			delete(s.watches, uri)
+		}
+	}
+}
+
+// UnsubscribeAll cancela todas las suscripciones de una sesión (ej: al cerrarse)
+func (s *Subscriptions) UnsubscribeAll(session string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for uri, w := range s.watches {
+		delete(w.sessions, session)
+		if len(w.sessions) == 0 {
+			delete(s.watches, uri)
+		}
+	}
+}
+
+// Run sondea las URIs suscritas cada cfg.PollInterval hasta que se cierre stop
+func (s *Subscriptions) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			uris := make([]string, 0, len(s.watches))
+			for uri := range s.watches {
+				uris = append(uris, uri)
+			}
+			s.mu.Unlock()
+
+			for _, uri := range uris {
+				s.check(uri)
+			}
+		}
+	}
+}
+
+// check consulta una URI suscrita y notifica a sus sesiones si hubo un cambio
+// significativo. Los errores (incluido el límite de llamadas) se reintentan en la
+// próxima vuelta
+func (s *Subscriptions) check(uri string) {
+	s.mu.Lock()
+	w, ok := s.watches[uri]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	location := w.location
+	s.mu.Unlock()
+
+	next, err := fetchSnapshot(context.Background(), s.cfg, location)
+	if errors.Is(err, errRateLimited) {
+		logEvent(logNotice, "subscriptions", "log.poll_deferred", map[string]interface{}{"uri": uri})
+		return
+	}
+	if err != nil {
+		logEvent(logWarning, "subscriptions", "log.poll_failed", map[string]interface{}{"uri": uri, "error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	w, ok = s.watches[uri]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	changed := w.last != nil && w.last.changed(next, s.cfg.WatchTempDelta)
+	switch {
+	case w.last == nil || changed:
+		w.last = next
+	default:
+		// La temperatura se compara contra la última notificada para acumular
+		// cambios lentos; las alertas vencidas se olvidan
+		w.last.Alerts = next.Alerts
+	}
+	var sessions []string
+	if changed {
+		for session := range w.sessions {
+			sessions = append(sessions, session)
+		}
+	}
+	s.mu.Unlock()
+
+	if changed {
+		logEvent(logInfo, "subscriptions", "log.weather_changed", map[string]interface{}{"uri": uri, "sessions": len(sessions)})
+	}
+	for _, session := range sessions {
+		s.notify(session, uri)
+	}
+}
+
+// changed indica si next difiere de forma significativa: variación de temperatura
+// de al menos delta °C, otro código de condición o una alerta nueva
+func (p *weatherSnapshot) changed(next *weatherSnapshot, delta float64) bool {
+	if math.Abs(next.TempC-p.TempC) >= delta || next.Code != p.Code {
+		return true
+	}
+	for headline := range next.Alerts {
+		if !p.Alerts[headline] {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchSnapshot consulta el clima actual y las alertas de una ubicación sin esperar
+// turno en el límite de llamadas
+func fetchSnapshot(ctx context.Context, cfg *config.Config, location string) (*weatherSnapshot, error) {
+	query := url.Values{}
+	query.Add("q", location)
+	query.Add("days", "1")
+	query.Add("aqi", "no")
+	query.Add("alerts", "yes")
+
+	var resp models.ForecastResponse
+	if err := requestWeatherAPI(ctx, cfg, i18n.For(cfg.Lang), "forecast.json", query, &resp, false); err != nil {
+		return nil, err
+	}
+
+	snapshot := &weatherSnapshot{
+		TempC:  resp.Current.TempC,
+		Code:   resp.Current.Condition.Code,
+		Alerts: map[string]bool{},
+	}
+	for _, alert := range resp.Alerts.Alert {
+		snapshot.Alerts[alert.Headline] = true
+	}
+	return snapshot, nil
+}
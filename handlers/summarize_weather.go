@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+	"weather-mcp-server/render"
+	"weather-mcp-server/units"
+)
+
+// Cómo se redactó el resumen
+const (
+	summaryMethodSampling = "sampling" // modelo del cliente con sampling/createMessage
+	summaryMethodRules    = "rules"    // resumen por reglas sin modelo
+)
+
+// maxSummaryDays días que se pueden resumir; el resumen debe ser breve
+const maxSummaryDays = 3
+
+// Umbrales (en unidades métricas) de los avisos del resumen por reglas
+const (
+	summaryRainChance   = 50.0 // %
+	summaryRainMm       = 1.0
+	summaryHeatC        = 32.0
+	summaryFrostC       = 0.0
+	summaryWindKph      = 40.0
+	summaryGustKph      = 60.0
+	summaryUV           = 6.0
+	summaryLowVisKm     = 5.0
+	summaryWarmClothesC = 12.0 // máxima por debajo de la que se recomienda abrigo
+	summaryLayersC      = 20.0 // máxima por debajo de la que se recomienda una capa
+)
+
+// summaryAudiences palabras clave de las audiencias con avisos propios en el resumen
+// por reglas; con sampling el modelo del cliente adapta el texto a cualquier audiencia
+var summaryAudiences = map[string][]string{
+	"aviation": {"pilot", "piloto", "aviat", "aviac", "vuelo", "flight", "dron"},
+	"family":   {"parent", "padre", "madre", "famil", "niño", "nino", "kid", "child", "hij", "school", "escuela", "colegio"},
+}
+
+// SummaryCurrent condiciones actuales que usan los resúmenes
+type SummaryCurrent struct {
+	Temp      float64 `json:"temperature"`
+	FeelsLike float64 `json:"feels_like"`
+	Condition string  `json:"condition"`
+	Wind      float64 `json:"wind"`
+	WindDir   string  `json:"wind_dir"`
+	Humidity  int     `json:"humidity"`
+}
+
+// SummaryDay día del pronóstico con los máximos y mínimos horarios que usan los resúmenes
+type SummaryDay struct {
+	ForecastDayData
+	ChanceOfRain  float64 `json:"chance_of_rain"`
+	MaxGust       float64 `json:"max_gust"`
+	MinVisibility float64 `json:"min_visibility"`
+	MaxCloud      int     `json:"max_cloud"`
+}
+
+// SummaryResult resultado estructurado de summarize_weather
+type SummaryResult struct {
+	Location      PlaceData      `json:"location"`
+	Units         units.System   `json:"units"`
+	Audience      string         `json:"audience,omitempty"`
+	Current       SummaryCurrent `json:"current"`
+	Days          []SummaryDay   `json:"days"`
+	Summary       string         `json:"summary"`
+	Method        string         `json:"method"`
+	Model         string         `json:"model,omitempty"`
+	SamplingError string         `json:"sampling_error,omitempty"`
+}
+
+// SummarizeWeather resume el pronóstico en lenguaje natural para una audiencia. Si el
+// cliente soporta sampling el texto lo redacta su modelo; si no, o si falla, se usa
+// el resumen por reglas
+func SummarizeWeather(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error) {
+	tr, err := langParam(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := requiredString(tr, params, "location")
+	if err != nil {
+		return nil, err
+	}
+
+	system, err := unitsParam(cfg, tr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	days := intParam(params, "days", 2)
+	if days < 1 || days > maxSummaryDays {
+		return nil, tr.Errorf("error.range", "days", 1, maxSummaryDays)
+	}
+	audience := strings.TrimSpace(stringParam(params, "audience", ""))
+
+	forecast, err := fetchForecast(ctx, cfg, tr, location, days)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SummaryResult{
+		Location: placeData(ctx, forecast.Location),
+		Units:    system,
+		Audience: audience,
+		Current: SummaryCurrent{
+			Temp:      roundTo(system.Temp(forecast.Current.TempC), 1),
+			FeelsLike: roundTo(system.Temp(forecast.Current.FeelslikeC), 1),
+			Condition: forecast.Current.Condition.Text,
+			Wind:      roundTo(system.SpeedValue(forecast.Current.WindKph), 1),
+			WindDir:   forecast.Current.WindDir,
+			Humidity:  forecast.Current.Humidity,
+		},
+	}
+	for _, day := range forecast.Forecast.Forecastday {
+		chance, gust, vis, cloud := summaryDayStats(day)
+		result.Days = append(result.Days, SummaryDay{
+			ForecastDayData: forecastDayData(day, system),
+			ChanceOfRain:    chance,
+			MaxGust:         roundTo(system.SpeedValue(gust), 1),
+			MinVisibility:   roundTo(system.DistanceValue(vis), 1),
+			MaxCloud:        cloud,
+		})
+	}
+
+	result.Summary = strings.Join(summarizeRules(tr, audience, forecast, system), "\n")
+	result.Method = summaryMethodRules
+	if sampler := samplerFrom(ctx); sampler != nil {
+		text, model, err := sampleSummary(ctx, sampler, tr, result)
+		switch {
+		case err == nil:
+			result.Summary, result.Method, result.Model = text, summaryMethodSampling, model
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		default:
+			result.SamplingError = err.Error()
+		}
+	}
+
+	return &ToolResult{
+		Structured: result,
+		Document:   summaryDocument(tr, result),
+	}, nil
+}
+
+// summaryDayStats máxima probabilidad de lluvia (%), ráfaga máxima (km/h), visibilidad
+// mínima (km) y nubosidad máxima (%) de las horas del día
+func summaryDayStats(day models.ForecastDay) (chance, gustKph, visKm float64, cloud int) {
+	visKm = day.Day.Avgvis_km
+	for i, h := range day.Hour {
+		if c := numberValue(h.ChanceOfRain); c > chance {
+			chance = c
+		}
+		if h.GustKph > gustKph {
+			gustKph = h.GustKph
+		}
+		if i == 0 || h.VisKm < visKm {
+			visKm = h.VisKm
+		}
+		if h.Cloud > cloud {
+			cloud = h.Cloud
+		}
+	}
+	return chance, gustKph, visKm, cloud
+}
+
+// sampleSummary pide el resumen al modelo del cliente con los datos del resultado
+func sampleSummary(ctx context.Context, sampler Sampler, tr i18n.Translator, r *SummaryResult) (string, string, error) {
+	data, err := json.MarshalIndent(struct {
+		Location PlaceData      `json:"location"`
+		Units    units.System   `json:"units"`
+		Current  SummaryCurrent `json:"current"`
+		Days     []SummaryDay   `json:"days"`
+	}{r.Location, r.Units, r.Current, r.Days}, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+
+	audience := r.Audience
+	if audience == "" {
+		audience = tr.T("summary.audience_default")
+	}
+
+	resp, err := sampler(ctx, SamplingRequest{
+		Messages: []SamplingMessage{{
+			Role:    "user",
+			Content: map[string]interface{}{"type": "text", "text": tr.T("summary.sampling_request", audience, string(data))},
+		}},
+		SystemPrompt:   tr.T("summary.system_prompt"),
+		IncludeContext: "none",
+		MaxTokens:      400,
+		ModelPreferences: map[string]interface{}{
+			"speedPriority":        0.7,
+			"intelligencePriority": 0.4,
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	text := strings.TrimSpace(resp.Content.Text)
+	if resp.Content.Type != "text" || text == "" {
+		return "", "", tr.Errorf("summary.error_sampling_content")
+	}
+	return text, resp.Model, nil
+}
+
+// summarizeRules resumen determinista: condiciones actuales, una frase por día, avisos
+// por lluvia, calor, heladas, viento y UV y los propios de la audiencia si se reconoce
+func summarizeRules(tr i18n.Translator, audience string, forecast *models.ForecastResponse, u units.System) []string {
+	current := forecast.Current
+	lines := []string{tr.T("summary.now", forecast.Location.Name, u.FormatTemp(current.TempC),
+		strings.ToLower(current.Condition.Text), u.FormatTemp(current.FeelslikeC), u.FormatSpeed(current.WindKph))}
+
+	var advice, audienceLines []string
+	kind := summaryAudience(audience)
+	for i, day := range forecast.Forecast.Forecastday {
+		label := summaryDayLabel(tr, i, day.Date)
+		chance, gust, vis, cloud := summaryDayStats(day)
+		d := day.Day
+
+		lines = append(lines, tr.T("summary.day", label, strings.ToLower(d.Condition.Text), u.FormatTemp(d.MintempC),
+			u.FormatTemp(d.MaxtempC), chance, u.FormatPrecip(d.TotalprecipMm), u.FormatSpeed(d.MaxwindKph)))
+
+		if chance >= summaryRainChance || d.TotalprecipMm >= summaryRainMm {
+			advice = append(advice, tr.T("summary.rain", label, chance))
+		}
+		if d.MaxtempC >= summaryHeatC {
+			advice = append(advice, tr.T("summary.heat", label, u.FormatTemp(d.MaxtempC)))
+		}
+		if d.MintempC <= summaryFrostC {
+			advice = append(advice, tr.T("summary.frost", label, u.FormatTemp(d.MintempC)))
+		}
+		if d.MaxwindKph >= summaryWindKph || gust >= summaryGustKph {
+			advice = append(advice, tr.T("summary.wind", label, u.FormatSpeed(gust)))
+		}
+		if d.UV >= summaryUV {
+			advice = append(advice, tr.T("summary.uv", label, d.UV))
+		}
+
+		switch kind {
+		case "aviation":
+			audienceLines = append(audienceLines, tr.T("summary.aviation", label, u.FormatDistance(vis), u.FormatSpeed(gust), cloud))
+			if vis < summaryLowVisKm {
+				audienceLines = append(audienceLines, tr.T("summary.aviation_low_visibility", label, u.FormatDistance(vis)))
+			}
+		case "family":
+			clothes := tr.T("summary.clothes.light")
+			if d.MaxtempC < summaryWarmClothesC {
+				clothes = tr.T("summary.clothes.warm")
+			} else if d.MaxtempC < summaryLayersC {
+				clothes = tr.T("summary.clothes.layers")
+			}
+			audienceLines = append(audienceLines, tr.T("summary.family", label, clothes))
+		}
+	}
+
+	if len(advice) == 0 {
+		advice = []string{tr.T("summary.calm")}
+	}
+	lines = append(lines, advice...)
+	return append(lines, audienceLines...)
+}
+
+// summaryAudience tipo de audiencia con avisos propios ("aviation", "family") o "" si
+// no se reconoce
+func summaryAudience(audience string) string {
+	audience = strings.ToLower(audience)
+	for kind, keywords := range summaryAudiences {
+		for _, keyword := range keywords {
+			if strings.Contains(audience, keyword) {
+				return kind
+			}
+		}
+	}
+	return ""
+}
+
+// summaryDayLabel "Hoy", "Mañana" o la fecha del día
+func summaryDayLabel(tr i18n.Translator, index int, date string) string {
+	switch index {
+	case 0:
+		return tr.T("summary.today")
+	case 1:
+		return tr.T("summary.tomorrow")
+	}
+	return date
+}
+
+// summaryDocument arma la presentación del resumen: una línea por frase o párrafo
+func summaryDocument(tr i18n.Translator, r *SummaryResult) *render.Document {
+	doc := &render.Document{
+		Icon:  "📝",
+		Title: tr.T("summary.title"),
+		Fields: []render.Field{
+			{Icon: "📍", Label: tr.T("label.location"), Value: placeLabel(r.Location)},
+		},
+	}
+	if r.Audience != "" {
+		doc.Fields = append(doc.Fields, render.Field{Icon: "👥", Label: tr.T("label.audience"), Value: r.Audience})
+	}
+	method := tr.T("summary.method_rules")
+	if r.Method == summaryMethodSampling {
+		method = tr.T("summary.method_sampling", r.Model)
+	}
+	doc.Fields = append(doc.Fields, render.Field{Icon: "🧠", Label: tr.T("label.method"), Value: method})
+
+	section := render.Section{Icon: "💬", Title: tr.T("summary.section")}
+	for _, line := range strings.Split(r.Summary, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			section.Fields = append(section.Fields, render.Field{Value: line})
+		}
+	}
+	doc.Sections = []render.Section{section}
+	doc.Summary = r.Location.Name + ": " + strings.Join(strings.Fields(r.Summary), " ")
+
+	if r.SamplingError != "" {
+		doc.Notes = []render.Field{{Icon: "⚠️", Value: tr.T("summary.sampling_failed", r.SamplingError)}}
+	}
+	return doc
+}
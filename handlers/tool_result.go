@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"weather-mcp-server/render"
+)
+
+// ToolResult resultado de una herramienta con texto legible y datos estructurados
+type ToolResult struct {
+	Text       string
+	Structured interface{}
+	// Document presentación neutral del resultado; CallTool la convierte en Text
+	// según el formato pedido
+	Document *render.Document
+	// Template nombre del resultado para elegir la plantilla propia
+	// ("<nombre>.<formato>.tmpl"); vacío usa el de la herramienta
+	Template string
+	// Images imágenes adjuntas (gráficos), enviadas como contenido MCP de tipo image
+	Images []ToolImage
+	// Links enlaces a recursos externos, enviados como contenido MCP de tipo
+	// resource_link (o como texto con versiones del protocolo anteriores)
+	Links []ToolLink
+	// Resources archivos generados (ej: CSV), enviados como contenido MCP de tipo resource
+	Resources []ToolResource
+	// IsError el resultado explica un problema que el modelo puede corregir (ej: una
+	// ubicación ambigua); se envía con isError
+	IsError bool
+}
+
+// ToolResource archivo de texto embebido en un resultado
+type ToolResource struct {
+	URI      string
+	MimeType string
+	Text     string
+}
+
+// ToolLink enlace a un recurso externo (ej: el ícono de la condición en el CDN de WeatherAPI)
+type ToolLink struct {
+	URI         string
+	Name        string
+	MimeType    string
+	Description string
+}
+
+// ToolImage imagen adjunta a un resultado
+type ToolImage struct {
+	MimeType string
+	Data     []byte
+}
+
+// resourceURI URI de un archivo generado, ej: weather://exports/madrid/forecast-day.csv
+func resourceURI(collection, slug, file string) string {
+	return fmt.Sprintf("weather://%s/%s/%s", collection, slug, file)
+}
+
+// uriSlug adapta un nombre para usarlo en una URI: minúsculas, dígitos y guiones
+func uriSlug(name string) string {
+	slug := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, name)
+	for strings.Contains(slug, "--") {
+		slug = strings.ReplaceAll(slug, "--", "-")
+	}
+	slug = strings.Trim(slug, "-")
+	if slug == "" {
+		return "location"
+	}
+	return slug
+}
+
+// String devuelve la representación de texto del resultado
+func (r *ToolResult) String() string {
+	return r.Text
+}
+
+// BuildToolResponse arma el resultado MCP de tools/call a partir del valor devuelto por
+// un handler, con los tipos de contenido de la versión del protocolo negociada
+func BuildToolResponse(result interface{}, protocol string) map[string]interface{} {
+	content := []map[string]interface{}{
+		{
+			"type": "text",
+			"text": fmt.Sprintf("%v", result),
+		},
+	}
+	response := map[string]interface{}{}
+
+	if toolResult, ok := result.(*ToolResult); ok {
+		for _, image := range toolResult.Images {
+			content = append(content, map[string]interface{}{
+				"type":     "image",
+				"data":     base64.StdEncoding.EncodeToString(image.Data),
+				"mimeType": image.MimeType,
+			})
+		}
+		for _, link := range toolResult.Links {
+			if !SupportsResourceLinks(protocol) {
+				// Los clientes de versiones anteriores rechazan tipos de contenido que no conocen
+				content = append(content, map[string]interface{}{
+					"type": "text",
+					"text": linkText(link),
+				})
+				continue
+			}
+			block := map[string]interface{}{
+				"type": "resource_link",
+				"uri":  link.URI,
+				"name": link.Name,
+			}
+			if link.MimeType != "" {
+				block["mimeType"] = link.MimeType
+			}
+			if link.Description != "" {
+				block["description"] = link.Description
+			}
+			content = append(content, block)
+		}
+		for _, resource := range toolResult.Resources {
+			content = append(content, map[string]interface{}{
+				"type": "resource",
+				"resource": map[string]interface{}{
+					"uri":      resource.URI,
+					"mimeType": resource.MimeType,
+					"text":     resource.Text,
+				},
+			})
+		}
+		if toolResult.Structured != nil {
+			response["structuredContent"] = toolResult.Structured
+		}
+		if toolResult.IsError {
+			response["isError"] = true
+		}
+	}
+
+	response["content"] = content
+	return response
+}
+
+// linkText enlace como texto: "nombre: URI (descripción)"
+func linkText(link ToolLink) string {
+	text := link.Name + ": " + link.URI
+	if link.Description != "" {
+		text += " (" + link.Description + ")"
+	}
+	return text
+}
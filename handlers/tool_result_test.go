@@ -0,0 +1,43 @@
+package handlers
+
+import "testing"
+
+func TestBuildToolResponseLinks(t *testing.T) {
+	result := &ToolResult{
+		Text:  "Madrid: 20°C",
+		Links: []ToolLink{{URI: "https://cdn.weatherapi.com/113.png", Name: "Soleado", MimeType: "image/png"}},
+	}
+	tests := []struct {
+		protocol string
+		want     string
+	}{
+		{ProtocolLatest, "resource_link"},
+		{ProtocolHTTPDefault, "text"},
+		{"2024-11-05", "text"},
+		{"", "text"},
+	}
+	for _, tt := range tests {
+		content := BuildToolResponse(result, tt.protocol)["content"].([]map[string]interface{})
+		if len(content) != 2 {
+			t.Fatalf("protocolo %q: %d bloques de contenido, se esperaban 2", tt.protocol, len(content))
+		}
+		if got := content[1]["type"]; got != tt.want {
+			t.Errorf("protocolo %q: enlace de tipo %v, se esperaba %s", tt.protocol, got, tt.want)
+		}
+	}
+
+	content := BuildToolResponse(result, "2024-11-05")["content"].([]map[string]interface{})
+	if got := content[1]["text"]; got != "Soleado: https://cdn.weatherapi.com/113.png" {
+		t.Errorf("texto del enlace = %q", got)
+	}
+}
+
+func TestBuildToolResponseIsError(t *testing.T) {
+	response := BuildToolResponse(&ToolResult{Text: "ambigua", IsError: true}, ProtocolLatest)
+	if response["isError"] != true {
+		t.Errorf("isError = %v, se esperaba true", response["isError"])
+	}
+	if _, ok := BuildToolResponse("texto", ProtocolLatest)["isError"]; ok {
+		t.Errorf("un resultado de texto no debería llevar isError")
+	}
+}
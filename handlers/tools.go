@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"weather-mcp-server/chart"
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/render"
+	"weather-mcp-server/units"
+)
+
+// ErrToolNotFound se devuelve al llamar una herramienta que no está registrada
+var ErrToolNotFound = errors.New("herramienta no encontrada")
+
+// ToolDefinition define una herramienta MCP
+type ToolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// ToolHandler firma común de los handlers de herramientas
+type ToolHandler func(ctx context.Context, cfg *config.Config, params map[string]interface{}) (interface{}, error)
+
+// toolHandlers handlers por nombre de herramienta
+var toolHandlers = map[string]ToolHandler{
+	"get_current_weather":   GetCurrentWeather,
+	"get_forecast":          GetForecastSimple,
+	"search_locations":      SearchLocations,
+	"get_astronomy":         GetAstronomySimple,
+	"compare_weather":       CompareWeather,
+	"score_activity":        ScoreActivity,
+	"get_route_weather":     GetRouteWeather,
+	"find_astronomy_events": FindAstronomyEvents,
+	"export_forecast":       ExportForecast,
+	"summarize_weather":     SummarizeWeather,
+}
+
+// templateResults tipo del resultado estructurado de cada herramienta, usado para
+// validar las plantillas propias al cargarlas. get_astronomy con end_date devuelve
+// un resultado distinto que tiene su propio nombre
+var templateResults = map[string]interface{}{
+	"get_current_weather":   CurrentWeatherResult{},
+	"get_forecast":          ForecastResult{},
+	"search_locations":      SearchResult{},
+	"get_astronomy":         AstronomyResult{},
+	"get_astronomy_range":   AstronomyRangeResult{},
+	"compare_weather":       ComparisonResult{},
+	"score_activity":        ActivityScoreResult{},
+	"get_route_weather":     RouteWeatherResult{},
+	"find_astronomy_events": AstronomyEventsResult{},
+	"export_forecast":       ExportResult{},
+	"summarize_weather":     SummaryResult{},
+	"ambiguous_location":    AmbiguousLocationResult{},
+}
+
+func init() {
+	for name, sample := range templateResults {
+		render.RegisterResult(name, sample)
+	}
+}
+
+// CallTool ejecuta la herramienta indicada y presenta el resultado en el formato
+// pedido ('format'); devuelve ErrToolNotFound si no existe y ctx.Err() si se
+// canceló ctx. El avance se informa por el ProgressFunc de WithProgress
+func CallTool(ctx context.Context, cfg *config.Config, name string, params map[string]interface{}) (interface{}, error) {
+	handler, exists := toolHandlers[name]
+	if !exists {
+		return nil, ErrToolNotFound
+	}
+
+	tr, _ := langParam(cfg, params)
+	format := stringParam(params, "format", cfg.Format)
+	if !render.Valid(format) {
+		return nil, tr.Errorf("error.unknown_format", format, strings.Join(render.Formats(), ", "))
+	}
+
+	// La 'location' se resuelve a su ubicación canónica antes de consultar; si tiene
+	// varias coincidencias la elige el usuario o se devuelven las candidatas
+	location, hasLocation := params["location"].(string)
+	if hasLocation {
+		var hint *ToolResult
+		var err error
+		if ctx, hint, err = resolveToolLocation(ctx, cfg, tr, params); err != nil {
+			return nil, err
+		}
+		if hint != nil {
+			return renderToolResult(tr, format, name, hint)
+		}
+		if resolved := canonicalFrom(ctx); resolved != nil && resolved.Name != "" {
+			location = resolved.label()
+		}
+	}
+
+	result, err := handler(ctx, cfg, params)
+	if ctx.Err() != nil {
+		// Cancelada: los errores por fila (compare_weather, rutas) no son el resultado
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hasLocation {
+		recentLocations.add(location)
+	}
+
+	// Identidad canónica de la ubicación para repetir la consulta
+	if resolved := canonicalFrom(ctx); resolved != nil {
+		if toolResult, ok := result.(*ToolResult); ok && toolResult.Document != nil {
+			toolResult.Document.Notes = append(toolResult.Document.Notes,
+				render.Field{Icon: "🆔", Label: tr.T("label.location_ref"), Value: resolved.Ref})
+			if len(resolved.Alternatives) > 0 {
+				toolResult.Document.Notes = append(toolResult.Document.Notes,
+					alternativesNote(tr, resolved.label(), resolved.Alternatives))
+			}
+		}
+	}
+
+	return renderToolResult(tr, format, name, result)
+}
+
+// renderToolResult convierte el Document del resultado en texto con la plantilla de
+// la herramienta (o la del resultado) en el formato pedido
+func renderToolResult(tr i18n.Translator, format, name string, result interface{}) (interface{}, error) {
+	if toolResult, ok := result.(*ToolResult); ok && toolResult.Document != nil {
+		templateName := toolResult.Template
+		if templateName == "" {
+			templateName = name
+		}
+		text, err := render.Render(format, templateName, tr.Lang, *toolResult.Document, toolResult.Structured)
+		if err != nil {
+			return nil, err
+		}
+		toolResult.Text = text
+	}
+	return result, nil
+}
+
+// ToolDefinitions devuelve las definiciones de todas las herramientas con las
+// descripciones en el idioma del traductor
+func ToolDefinitions(tr i18n.Translator) []ToolDefinition {
+	compareMetricKeys := make([]string, 0, len(compareMetrics))
+	for _, m := range compareMetrics {
+		compareMetricKeys = append(compareMetricKeys, m.Key)
+	}
+
+	return []ToolDefinition{
+		{
+			Name:        "get_current_weather",
+			Description: tr.T("tool.get_current_weather"),
+			InputSchema: toolSchema(tr, []string{"location"}, map[string]interface{}{
+				"location": locationProperty(tr),
+				"aqi": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.get_current_weather.aqi"),
+					"default":     "no",
+				},
+				"units": unitsProperty(tr),
+				"icons": iconsProperty(tr),
+			}),
+		},
+		{
+			Name:        "get_forecast",
+			Description: tr.T("tool.get_forecast"),
+			InputSchema: toolSchema(tr, []string{"location"}, map[string]interface{}{
+				"location": locationProperty(tr),
+				"days": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.get_forecast.days"),
+					"default":     3,
+				},
+				"units": unitsProperty(tr),
+				"icons": iconsProperty(tr),
+				"chart": chartProperty(tr),
+				"chart_interval": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{chartIntervalDay, chartIntervalHour},
+					"description": tr.T("tool.get_forecast.chart_interval"),
+					"default":     chartIntervalDay,
+				},
+			}),
+		},
+		{
+			Name:        "search_locations",
+			Description: tr.T("tool.search_locations"),
+			InputSchema: toolSchema(tr, []string{"query"}, map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.search_locations.query"),
+				},
+				"country": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.search_locations.country"),
+				},
+				"region": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.search_locations.region"),
+				},
+				"geojson": geojsonProperty(tr),
+			}),
+		},
+		{
+			Name:        "get_astronomy",
+			Description: tr.T("tool.get_astronomy"),
+			InputSchema: toolSchema(tr, []string{"location"}, map[string]interface{}{
+				"location": locationProperty(tr),
+				"date": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.get_astronomy.date"),
+				},
+				"end_date": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.get_astronomy.end_date"),
+				},
+				"timezone": timezoneProperty(tr),
+			}),
+		},
+		{
+			Name:        "compare_weather",
+			Description: tr.T("tool.compare_weather"),
+			InputSchema: toolSchema(tr, []string{"locations"}, map[string]interface{}{
+				"locations": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": tr.T("tool.compare_weather.locations"),
+				},
+				"metrics": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "string",
+						"enum": compareMetricKeys,
+					},
+					"description": tr.T("tool.compare_weather.metrics"),
+				},
+				"sort_by": map[string]interface{}{
+					"type":        "string",
+					"enum":        compareMetricKeys,
+					"description": tr.T("tool.compare_weather.sort_by"),
+				},
+				"order": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"desc", "asc"},
+					"description": tr.T("tool.compare_weather.order"),
+					"default":     "desc",
+				},
+				"day": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.compare_weather.day"),
+				},
+				"units":   unitsProperty(tr),
+				"geojson": geojsonProperty(tr),
+			}),
+		},
+		{
+			Name:        "score_activity",
+			Description: tr.T("tool.score_activity"),
+			InputSchema: toolSchema(tr, []string{"location", "activity"}, map[string]interface{}{
+				"location": locationProperty(tr),
+				"activity": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.score_activity.activity"),
+				},
+				"start": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.score_activity.start"),
+				},
+				"end": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.score_activity.end"),
+				},
+				"duration": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.score_activity.duration"),
+					"default":     1,
+				},
+				"min_score": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.score_activity.min_score"),
+					"default":     60,
+				},
+				"limit": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.score_activity.limit"),
+					"default":     3,
+				},
+				"units": unitsProperty(tr),
+				"chart": chartProperty(tr),
+			}),
+		},
+		{
+			Name:        "get_route_weather",
+			Description: tr.T("tool.get_route_weather"),
+			InputSchema: toolSchema(tr, []string{"waypoints"}, map[string]interface{}{
+				"waypoints": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": tr.T("tool.get_route_weather.waypoints"),
+				},
+				"departure": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.get_route_weather.departure"),
+				},
+				"speed_kph": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.get_route_weather.speed_kph"),
+					"default":     80,
+				},
+				"road_factor": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.get_route_weather.road_factor"),
+					"default":     1.2,
+				},
+				"units":   unitsProperty(tr),
+				"geojson": geojsonProperty(tr),
+			}),
+		},
+		{
+			Name:        "find_astronomy_events",
+			Description: tr.T("tool.find_astronomy_events"),
+			InputSchema: toolSchema(tr, []string{"location", "event"}, map[string]interface{}{
+				"location": locationProperty(tr),
+				"event": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"full_moon", "new_moon", "first_quarter", "last_quarter", "sunrise_before", "sunrise_after", "sunset_before", "sunset_after", "longest_day", "shortest_day"},
+					"description": tr.T("tool.find_astronomy_events.event"),
+				},
+				"time": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.find_astronomy_events.time"),
+				},
+				"start_date": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.find_astronomy_events.start_date"),
+				},
+				"end_date": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.find_astronomy_events.end_date"),
+				},
+				"count": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.find_astronomy_events.count"),
+					"default":     1,
+				},
+				"timezone": timezoneProperty(tr),
+			}),
+		},
+		{
+			Name:        "export_forecast",
+			Description: tr.T("tool.export_forecast"),
+			InputSchema: toolSchema(tr, []string{"location"}, map[string]interface{}{
+				"location": locationProperty(tr),
+				"export": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{exportCSV, exportNDJSON, exportICS},
+					"description": tr.T("tool.export_forecast.export"),
+					"default":     exportCSV,
+				},
+				"interval": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{chartIntervalDay, chartIntervalHour},
+					"description": tr.T("tool.export_forecast.interval"),
+					"default":     chartIntervalDay,
+				},
+				"days": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.export_forecast.days"),
+				},
+				"history_days": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.export_forecast.history_days"),
+					"default":     0,
+				},
+				"start_date": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.export_forecast.start_date"),
+				},
+				"units":    unitsProperty(tr),
+				"timezone": timezoneProperty(tr),
+			}),
+		},
+		{
+			Name:        "summarize_weather",
+			Description: tr.T("tool.summarize_weather"),
+			InputSchema: toolSchema(tr, []string{"location"}, map[string]interface{}{
+				"location": locationProperty(tr),
+				"audience": map[string]interface{}{
+					"type":        "string",
+					"description": tr.T("tool.summarize_weather.audience"),
+				},
+				"days": map[string]interface{}{
+					"type":        "number",
+					"description": tr.T("tool.summarize_weather.days"),
+					"default":     2,
+				},
+				"units": unitsProperty(tr),
+			}),
+		},
+	}
+}
+
+// toolSchema arma el inputSchema de una herramienta; todas aceptan 'lang' y 'format'
+func toolSchema(tr i18n.Translator, required []string, properties map[string]interface{}) map[string]interface{} {
+	properties["lang"] = map[string]interface{}{
+		"type":        "string",
+		"enum":        i18n.Languages(),
+		"description": tr.T("param.lang"),
+	}
+	properties["format"] = map[string]interface{}{
+		"type":        "string",
+		"enum":        render.Formats(),
+		"description": tr.T("param.format"),
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// locationProperty propiedad 'location' común a las herramientas del clima
+func locationProperty(tr i18n.Translator) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": tr.T("param.location"),
+	}
+}
+
+// unitsProperty propiedad 'units' común a las herramientas con mediciones
+func unitsProperty(tr i18n.Translator) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"enum":        units.Names(),
+		"description": tr.T("param.units"),
+	}
+}
+
+// chartProperty propiedad 'chart' de las herramientas que pueden adjuntar un gráfico
+func chartProperty(tr i18n.Translator) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"enum":        chart.Formats(),
+		"description": tr.T("param.chart"),
+	}
+}
+
+// iconsProperty propiedad 'icons' de las herramientas que informan la condición
+func iconsProperty(tr i18n.Translator) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": tr.T("param.icons"),
+		"default":     false,
+	}
+}
+
+// geojsonProperty propiedad 'geojson' de las herramientas que devuelven varias ubicaciones
+func geojsonProperty(tr i18n.Translator) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": tr.T("param.geojson"),
+		"default":     false,
+	}
+}
+
+// timezoneProperty propiedad 'timezone' de las herramientas astronómicas
+func timezoneProperty(tr i18n.Translator) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": tr.T("param.timezone"),
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"weather-mcp-server/config"
+)
+
+// errRateLimited el sondeo en segundo plano no espera turno: si no quedan llamadas
+// disponibles lo intenta en la próxima vuelta
+var errRateLimited = errors.New("límite de llamadas a WeatherAPI alcanzado")
+
+// maxCacheEntries respuestas guardadas como máximo antes de descartar las vencidas
+const maxCacheEntries = 1000
+
+// Caché y límite de llamadas compartidos por herramientas, recursos y sondeo;
+// se crean con la configuración de la primera llamada
+var (
+	upstreamOnce    sync.Once
+	upstreamCache   *responseCache
+	upstreamLimiter *rateLimiter
+)
+
+// upstream devuelve la caché y el límite de llamadas a WeatherAPI
+func upstream(cfg *config.Config) (*responseCache, *rateLimiter) {
+	upstreamOnce.Do(func() {
+		upstreamCache = &responseCache{ttl: cfg.CacheTTL, entries: map[string]cacheEntry{}}
+		upstreamLimiter = &rateLimiter{perMinute: cfg.RateLimit, tokens: float64(cfg.RateLimit), last: time.Now()}
+	})
+	return upstreamCache, upstreamLimiter
+}
+
+// cacheEntry respuesta guardada y su vencimiento
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+// responseCache respuestas de WeatherAPI por URL (sin la API key) durante un TTL
+type responseCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+// get devuelve la respuesta guardada si no venció
+func (c *responseCache) get(key string) ([]byte, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.body, true
+}
+
+// put guarda una respuesta; al llenarse descarta primero las vencidas
+func (c *responseCache) put(key string, body []byte) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxCacheEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) >= maxCacheEntries {
+		return
+	}
+	c.entries[key] = cacheEntry{body: body, expires: now.Add(c.ttl)}
+}
+
+// rateLimiter balde de fichas: perMinute llamadas por minuto con ráfagas de hasta
+// perMinute; 0 no limita
+type rateLimiter struct {
+	mu        sync.Mutex
+	perMinute int
+	tokens    float64
+	last      time.Time
+}
+
+// reserve toma una ficha si hay; si no, devuelve cuánto falta para la próxima
+func (l *rateLimiter) reserve() time.Duration {
+	if l.perMinute <= 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Minutes() * float64(l.perMinute)
+	if l.tokens > float64(l.perMinute) {
+		l.tokens = float64(l.perMinute)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / float64(l.perMinute) * float64(time.Minute))
+}
+
+// wait espera hasta tener una ficha y devuelve cuánto esperó; deja de esperar si
+// se cancela ctx
+func (l *rateLimiter) wait(ctx context.Context) (time.Duration, error) {
+	var waited time.Duration
+	for d := l.reserve(); d > 0; d = l.reserve() {
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return waited, ctx.Err()
+		case <-timer.C:
+		}
+		waited += d
+	}
+	return waited, nil
+}
+
+// allow toma una ficha si hay, sin esperar
+func (l *rateLimiter) allow() bool {
+	return l.reserve() == 0
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+)
+
+func TestResponseCache(t *testing.T) {
+	c := &responseCache{ttl: time.Minute, entries: map[string]cacheEntry{}}
+	c.put("current.json?q=madrid", []byte("madrid"))
+	if body, ok := c.get("current.json?q=madrid"); !ok || string(body) != "madrid" {
+		t.Errorf("get = %q, %v; se esperaba madrid", body, ok)
+	}
+	if _, ok := c.get("current.json?q=lima"); ok {
+		t.Errorf("get de una clave que no se guardó")
+	}
+
+	// Vencida
+	c.entries["old"] = cacheEntry{body: []byte("old"), expires: time.Now().Add(-time.Second)}
+	if _, ok := c.get("old"); ok {
+		t.Errorf("get devolvió una respuesta vencida")
+	}
+
+	// Sin TTL no guarda nada
+	off := &responseCache{entries: map[string]cacheEntry{}}
+	off.put("a", []byte("a"))
+	if _, ok := off.get("a"); ok || len(off.entries) != 0 {
+		t.Errorf("con TTL 0 la caché guardó la respuesta")
+	}
+}
+
+func TestResponseCacheFull(t *testing.T) {
+	c := &responseCache{ttl: time.Minute, entries: map[string]cacheEntry{}}
+	for i := 0; i < maxCacheEntries; i++ {
+		c.put(fmt.Sprint(i), []byte("x"))
+	}
+	// Llena y sin vencidas: la nueva no se guarda
+	c.put("new", []byte("x"))
+	if _, ok := c.get("new"); ok {
+		t.Errorf("se guardó una respuesta con la caché llena")
+	}
+
+	// Con vencidas se descartan para hacer lugar
+	for i := 0; i < 10; i++ {
+		c.entries[fmt.Sprint(i)] = cacheEntry{expires: time.Now().Add(-time.Second)}
+	}
+	c.put("new", []byte("x"))
+	if _, ok := c.get("new"); !ok {
+		t.Errorf("no se descartaron las vencidas para guardar la nueva")
+	}
+	if len(c.entries) != maxCacheEntries-10+1 {
+		t.Errorf("la caché tiene %d entradas, se esperaban %d", len(c.entries), maxCacheEntries-10+1)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := &rateLimiter{perMinute: 3, tokens: 3, last: time.Now()}
+	for i := 0; i < 3; i++ {
+		if !l.allow() {
+			t.Fatalf("llamada %d rechazada dentro de la ráfaga", i+1)
+		}
+	}
+	if l.allow() {
+		t.Errorf("se permitió una llamada sin fichas")
+	}
+	// Con 3 por minuto la próxima ficha llega en unos 20 s
+	if d := l.reserve(); d < 19*time.Second || d > 20*time.Second {
+		t.Errorf("reserve = %s, se esperaban unos 20 s", d)
+	}
+
+	// Las fichas se reponen con el tiempo, sin pasar de perMinute
+	l.last = time.Now().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		if !l.allow() {
+			t.Errorf("llamada %d rechazada después de reponerse", i+1)
+		}
+	}
+	if l.allow() {
+		t.Errorf("las fichas superaron perMinute")
+	}
+
+	unlimited := &rateLimiter{}
+	for i := 0; i < 100; i++ {
+		if !unlimited.allow() {
+			t.Fatalf("con perMinute 0 no debería limitar")
+		}
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	l := &rateLimiter{perMinute: 1, tokens: 0, last: time.Now()}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := l.wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("wait: err = %v, se esperaba context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("wait tardó %s en devolver tras cancelarse", elapsed)
+	}
+}
+
+func TestRequestWeatherAPIRetry(t *testing.T) {
+	previous := apiRetryDelay
+	apiRetryDelay = time.Millisecond
+	defer func() { apiRetryDelay = previous }()
+
+	tests := []struct {
+		name     string
+		statuses []int // respuestas sucesivas; la última se repite
+		wantErr  bool
+		wantHits int32
+	}{
+		{"5xx y luego bien", []int{http.StatusServiceUnavailable, http.StatusOK}, false, 2},
+		{"siempre 5xx", []int{http.StatusInternalServerError}, true, 1 + maxAPIRetries},
+		{"4xx sin reintento", []int{http.StatusBadRequest}, true, 1},
+	}
+	for _, tt := range tests {
+		var hits int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hit := int(atomic.AddInt32(&hits, 1))
+			status := tt.statuses[len(tt.statuses)-1]
+			if hit <= len(tt.statuses) {
+				status = tt.statuses[hit-1]
+			}
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{}`)
+		}))
+
+		cfg := &config.Config{BaseURL: server.URL, WeatherAPIKey: "test", RateLimit: 60}
+		var out map[string]interface{}
+		err := getWeatherAPI(context.Background(), cfg, i18n.For("es"), "current.json", url.Values{"q": {tt.name}}, &out)
+		server.Close()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+		if hits != tt.wantHits {
+			t.Errorf("%s: %d consultas, se esperaban %d", tt.name, hits, tt.wantHits)
+		}
+	}
+}
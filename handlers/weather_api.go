@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"weather-mcp-server/config"
+	"weather-mcp-server/i18n"
+	"weather-mcp-server/models"
+)
+
+// getWeatherAPI hace un GET a un endpoint de WeatherAPI y decodifica el JSON en out.
+// Las condiciones (condition.text) llegan en el idioma del traductor
+func getWeatherAPI(ctx context.Context, cfg *config.Config, tr i18n.Translator, endpoint string, query url.Values, out interface{}) error {
+	return requestWeatherAPI(ctx, cfg, tr, endpoint, query, out, true)
+}
+
+// maxAPIRetries reintentos de una consulta a WeatherAPI que falló por un error 5xx o
+// por tiempo de espera agotado
+const maxAPIRetries = 2
+
+// apiRetryDelay espera antes del primer reintento; el segundo espera el doble
+var apiRetryDelay = 500 * time.Millisecond
+
+// requestWeatherAPI consulta WeatherAPI pasando por la caché y el límite de llamadas
+// compartidos. Con wait en false no espera turno y devuelve errRateLimited. Los errores
+// 5xx y los tiempos de espera agotados se reintentan hasta maxAPIRetries veces, cada
+// vez con su turno en el límite de llamadas. Si se cancela ctx se aborta la consulta y
+// se devuelve ctx.Err()
+func requestWeatherAPI(ctx context.Context, cfg *config.Config, tr i18n.Translator, endpoint string, query url.Values, out interface{}, wait bool) error {
+	if lang := tr.WeatherAPILang(); lang != "" {
+		query.Set("lang", lang)
+	}
+	cacheKey := fmt.Sprintf("%s/%s?%s", cfg.BaseURL, endpoint, query.Encode())
+	query.Set("key", cfg.WeatherAPIKey)
+	fullURL := fmt.Sprintf("%s/%s?%s", cfg.BaseURL, endpoint, query.Encode())
+
+	cache, limiter := upstream(cfg)
+	if body, ok := cache.get(cacheKey); ok {
+		logEvent(logDebug, "cache", "log.cache_hit", map[string]interface{}{"url": cacheKey})
+		return decodeWeatherAPI(tr, body, out)
+	}
+	logEvent(logDebug, "cache", "log.cache_miss", map[string]interface{}{"url": cacheKey})
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(apiRetryDelay * time.Duration(attempt)):
+			}
+		}
+		if wait {
+			waited, err := limiter.wait(ctx)
+			if err != nil {
+				return err
+			}
+			if waited > 0 {
+				logEvent(logWarning, "ratelimit", "log.rate_limit_waited", map[string]interface{}{
+					"url":       cacheKey,
+					"waited_ms": waited.Milliseconds(),
+				})
+			}
+		} else if !limiter.allow() {
+			logEvent(logNotice, "ratelimit", "log.rate_limit_deferred", map[string]interface{}{"url": cacheKey})
+			return errRateLimited
+		}
+
+		body, retry, err := fetchWeatherAPI(ctx, tr, fullURL, cacheKey)
+		if err == nil {
+			if err := decodeWeatherAPI(tr, body, out); err != nil {
+				return err
+			}
+			cache.put(cacheKey, body)
+			return nil
+		}
+		if !retry || attempt == maxAPIRetries {
+			return err
+		}
+		logEvent(logWarning, "weatherapi", "log.api_retry", map[string]interface{}{
+			"url":     cacheKey,
+			"attempt": attempt + 1,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// fetchWeatherAPI hace un GET a WeatherAPI y devuelve el cuerpo de la respuesta 200;
+// retry indica si el error vale un reintento (5xx o tiempo de espera agotado)
+func fetchWeatherAPI(ctx context.Context, tr i18n.Translator, fullURL, cacheKey string) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, false, tr.Errorf("error.api_connect", err)
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if ctx.Err() != nil {
+		// Cancelada por el cliente: no es un error de WeatherAPI
+		return nil, false, ctx.Err()
+	}
+	if err != nil {
+		logEvent(logError, "weatherapi", "log.api_connect", map[string]interface{}{"url": cacheKey, "error": err.Error()})
+		var netErr net.Error
+		return nil, errors.As(err, &netErr) && netErr.Timeout(), tr.Errorf("error.api_connect", err)
+	}
+	defer resp.Body.Close()
+
+	fields := map[string]interface{}{
+		"url":         cacheKey,
+		"status":      resp.StatusCode,
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		logEvent(logInfo, "weatherapi", "log.api_request", fields)
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		// WeatherAPI responde 403 al agotar la cuota mensual de la API key
+		logEvent(logWarning, "weatherapi", "log.api_rejected", fields)
+	default:
+		logEvent(logError, "weatherapi", "log.api_error", fields)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode >= 500, tr.Errorf("error.api_status", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+	if err != nil {
+		return nil, false, tr.Errorf("error.api_connect", err)
+	}
+	return body, false, nil
+}
+
+// decodeWeatherAPI decodifica una respuesta JSON de WeatherAPI
+func decodeWeatherAPI(tr i18n.Translator, body []byte, out interface{}) error {
+	if err := json.Unmarshal(body, out); err != nil {
+		return tr.Errorf("error.api_decode", err)
+	}
+	return nil
+}
+
+// fetchCurrent obtiene el clima actual completo de una ubicación
+func fetchCurrent(ctx context.Context, cfg *config.Config, tr i18n.Translator, location string) (*models.CurrentWeatherResponse, error) {
+	query := url.Values{}
+	query.Add("q", location)
+	query.Add("aqi", "no")
+
+	var resp models.CurrentWeatherResponse
+	if err := getWeatherAPI(ctx, cfg, tr, "current.json", query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// fetchForecast obtiene el pronóstico completo (incluye datos por hora) de una ubicación
+func fetchForecast(ctx context.Context, cfg *config.Config, tr i18n.Translator, location string, days int) (*models.ForecastResponse, error) {
+	query := url.Values{}
+	query.Add("q", location)
+	query.Add("days", strconv.Itoa(days))
+	query.Add("aqi", "no")
+	query.Add("alerts", "no")
+
+	var resp models.ForecastResponse
+	if err := getWeatherAPI(ctx, cfg, tr, "forecast.json", query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// fetchHistory obtiene el tiempo observado (incluye datos por hora) de una fecha pasada.
+// El plan de WeatherAPI limita cuántos días hacia atrás están disponibles
+func fetchHistory(ctx context.Context, cfg *config.Config, tr i18n.Translator, location, date string) (*models.ForecastResponse, error) {
+	query := url.Values{}
+	query.Add("q", location)
+	query.Add("dt", date)
+
+	var resp models.ForecastResponse
+	if err := getWeatherAPI(ctx, cfg, tr, "history.json", query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// numberValue convierte campos de WeatherAPI que pueden venir como string o number
+func numberValue(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+// fetchSearch busca ubicaciones que coincidan con la consulta
+func fetchSearch(ctx context.Context, cfg *config.Config, tr i18n.Translator, query string) (models.SearchLocationResponse, error) {
+	values := url.Values{}
+	values.Add("q", query)
+
+	var resp models.SearchLocationResponse
+	if err := getWeatherAPI(ctx, cfg, tr, "search.json", values, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
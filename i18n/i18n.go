@@ -0,0 +1,171 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLang idioma base: toda clave debe existir en este catálogo
+const DefaultLang = "es"
+
+//go:embed locales/*.json
+var builtinLocales embed.FS
+
+var (
+	mu       sync.RWMutex
+	catalogs = map[string]map[string]string{}
+)
+
+func init() {
+	entries, err := builtinLocales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		data, err := builtinLocales.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		if err := register(strings.TrimSuffix(entry.Name(), ".json"), data); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// LoadDir agrega o completa catálogos con los archivos <idioma>.json de dir
+func LoadDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("no se pudo leer %s: %v", file, err)
+		}
+		if err := register(strings.TrimSuffix(filepath.Base(file), ".json"), data); err != nil {
+			return fmt.Errorf("catálogo inválido %s: %v", file, err)
+		}
+	}
+	return nil
+}
+
+// register fusiona un catálogo JSON (clave → mensaje) con el existente para ese idioma
+func register(lang string, data []byte) error {
+	messages := map[string]string{}
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	lang = strings.ToLower(lang)
+	if catalogs[lang] == nil {
+		catalogs[lang] = map[string]string{}
+	}
+	for key, message := range messages {
+		catalogs[lang][key] = message
+	}
+	return nil
+}
+
+// Languages devuelve los idiomas disponibles
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Normalize convierte etiquetas como "en-US" o "es_AR" al idioma disponible;
+// devuelve "" si no hay catálogo para ese idioma
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return ""
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	if _, ok := catalogs[tag]; ok {
+		return tag
+	}
+	if i := strings.IndexAny(tag, "-_"); i > 0 {
+		if _, ok := catalogs[tag[:i]]; ok {
+			return tag[:i]
+		}
+	}
+	return ""
+}
+
+// Translator traduce mensajes a un idioma, con el idioma base como respaldo
+type Translator struct {
+	Lang string
+}
+
+// For devuelve el traductor del idioma indicado (o del idioma base si no existe)
+func For(lang string) Translator {
+	if normalized := Normalize(lang); normalized != "" {
+		return Translator{Lang: normalized}
+	}
+	return Translator{Lang: DefaultLang}
+}
+
+// T devuelve el mensaje de key formateado con args (verbos de fmt)
+func (t Translator) T(key string, args ...interface{}) string {
+	mu.RLock()
+	message, ok := catalogs[t.Lang][key]
+	if !ok {
+		message, ok = catalogs[DefaultLang][key]
+	}
+	mu.RUnlock()
+
+	if !ok {
+		message = key
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Errorf crea un error con el mensaje traducido de key
+func (t Translator) Errorf(key string, args ...interface{}) error {
+	return fmt.Errorf("%s", t.T(key, args...))
+}
+
+// WeatherAPILang código de idioma para el parámetro 'lang' de WeatherAPI
+// ("" para inglés, que es el idioma por defecto de la API)
+func (t Translator) WeatherAPILang() string {
+	if t.Lang == "en" {
+		return ""
+	}
+	return t.Lang
+}
+
+// Lookup devuelve el mensaje de key sin formatear e indica si existe en algún catálogo
+func (t Translator) Lookup(key string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if message, ok := catalogs[t.Lang][key]; ok {
+		return message, true
+	}
+	message, ok := catalogs[DefaultLang][key]
+	return message, ok
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"weather-mcp-server/config"
 	"weather-mcp-server/handlers"
+	"weather-mcp-server/i18n"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -16,9 +24,19 @@ import (
 
 // MCPServer representa nuestro servidor MCP
 type MCPServer struct {
-	config *config.Config
+	config        *config.Config
+	subscriptions *handlers.Subscriptions
+
+	// sessions canal de mensajes de cada conexión SSE abierta, por id de sesión;
+	// inflight cancelación de las herramientas en curso, por sesión e id de solicitud
+	mu       sync.Mutex
+	sessions map[string]chan []byte
+	inflight map[string]context.CancelFunc
 }
 
+// sseKeepAlive cada cuánto se envía un comentario para mantener abierta la conexión SSE
+const sseKeepAlive = 30 * time.Second
+
 // MCPRequest estructura de request MCP estándar
 type MCPRequest struct {
 	ID     string                 `json:"id"`
@@ -39,13 +57,6 @@ type MCPError struct {
 	Message string `json:"message"`
 }
 
-// ToolDefinition define las herramientas disponibles
-type ToolDefinition struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
-}
-
 func main() {
 	// Cargar configuración
 	cfg, err := config.LoadConfig()
@@ -53,13 +64,23 @@ func main() {
 		log.Fatalf("Error cargando configuración: %v", err)
 	}
 
-	server := &MCPServer{config: cfg}
+	server := &MCPServer{
+		config:   cfg,
+		sessions: map[string]chan []byte{},
+		inflight: map[string]context.CancelFunc{},
+	}
+	server.subscriptions = handlers.NewSubscriptions(cfg, server.notifyResourceUpdated)
+	go server.subscriptions.Run(make(chan struct{}))
+	handlers.SetLogNotify(func(session string, message handlers.LogMessage) {
+		server.notify(session, "notifications/message", message)
+	})
 
 	// Configurar rutas
 	r := mux.NewRouter()
 
 	// Rutas MCP estándar
 	r.HandleFunc("/", server.handleMCPRequest).Methods("POST")
+	r.HandleFunc("/sse", server.handleSSE).Methods("GET")
 	r.HandleFunc("/tools", server.listTools).Methods("GET")
 	r.HandleFunc("/health", server.healthCheck).Methods("GET")
 
@@ -83,6 +104,19 @@ func main() {
 	fmt.Printf("   - get_forecast: Pronóstico del tiempo\n")
 	fmt.Printf("   - search_locations: Buscar ubicaciones\n")
 	fmt.Printf("   - get_astronomy: Datos astronómicos\n")
+	fmt.Printf("   - compare_weather: Comparar varias ubicaciones\n")
+	fmt.Printf("   - score_activity: Aptitud del clima para actividades\n")
+	fmt.Printf("   - get_route_weather: Clima a lo largo de una ruta\n")
+	fmt.Printf("   - find_astronomy_events: Fases lunares y eventos solares\n")
+	fmt.Printf("   - export_forecast: Exportar a CSV, NDJSON o iCalendar\n")
+	fmt.Printf("   - summarize_weather: Resumen en lenguaje natural para una audiencia\n")
+	fmt.Printf("📎 Recursos: weather://current/{location}, weather://forecast/{location}/{days}, weather://astronomy/{location}/{date}\n")
+	fmt.Printf("💬 Prompts: daily_briefing, travel_packing, outdoor_event_go_no_go\n")
+	fmt.Printf("🔔 Suscripciones por SSE en GET /sse (sondeo cada %s)\n", cfg.PollInterval)
+	fmt.Printf("🌐 Idioma por defecto: %s (disponibles: %s)\n", cfg.Lang, strings.Join(i18n.Languages(), ", "))
+	if cfg.Gazetteer != nil {
+		fmt.Printf("🗺️  Gazetteer: %d lugares (modo %s)\n", cfg.Gazetteer.Len(), cfg.GazetteerMode)
+	}
 	fmt.Printf("📚 API Key: %s\n", cfg.MaskAPIKey())
 
 	log.Fatal(http.ListenAndServe(":"+port, handler))
@@ -90,72 +124,376 @@ func main() {
 
 // handleMCPRequest maneja todas las solicitudes MCP
 func (s *MCPServer) handleMCPRequest(w http.ResponseWriter, r *http.Request) {
+	tr := s.translator(r)
+
 	var req MCPRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.sendError(w, req.ID, 400, "Request JSON inválido")
+		s.sendError(w, req.ID, 400, tr.T("server.invalid_json"))
 		return
 	}
 
 	switch req.Method {
+	case "initialize":
+		requested, _ := req.Params["protocolVersion"].(string)
+		s.sendResponse(w, req.ID, handlers.InitializeResult(handlers.NegotiateProtocol(requested)))
 	case "tools/list":
-		s.sendResponse(w, req.ID, s.getToolDefinitions())
+		s.sendResponse(w, req.ID, s.getToolDefinitions(tr))
 	case "tools/call":
-		s.handleToolCall(w, req)
+		s.handleToolCall(w, r, req)
+	case "notifications/cancelled":
+		s.handleCancelled(w, r, req)
+	case "resources/list":
+		s.sendResponse(w, req.ID, map[string]interface{}{
+			"resources": handlers.ListResources(s.config, tr),
+		})
+	case "resources/templates/list":
+		s.sendResponse(w, req.ID, map[string]interface{}{
+			"resourceTemplates": handlers.ResourceTemplates(tr),
+		})
+	case "resources/read":
+		s.handleResourceRead(w, r, req)
+	case "resources/subscribe", "resources/unsubscribe":
+		s.handleResourceSubscription(w, r, req)
+	case "prompts/list":
+		s.sendResponse(w, req.ID, map[string]interface{}{
+			"prompts": handlers.PromptDefinitions(tr),
+		})
+	case "prompts/get":
+		s.handlePromptGet(w, r, req)
+	case "completion/complete":
+		s.handleCompletion(w, r, req)
+	case "logging/setLevel":
+		s.handleSetLogLevel(w, r, req)
 	default:
-		s.sendError(w, req.ID, 404, "Método no encontrado")
+		s.sendError(w, req.ID, 404, tr.T("server.method_not_found", req.Method))
 	}
 }
 
 // handleToolCall ejecuta las herramientas
-func (s *MCPServer) handleToolCall(w http.ResponseWriter, req MCPRequest) {
+func (s *MCPServer) handleToolCall(w http.ResponseWriter, r *http.Request, req MCPRequest) {
+	tr := s.translator(r)
+
 	params, ok := req.Params["arguments"].(map[string]interface{})
 	if !ok {
-		s.sendError(w, req.ID, 400, "Argumentos inválidos")
+		s.sendError(w, req.ID, 400, tr.T("server.invalid_arguments"))
 		return
 	}
 
 	toolName, ok := req.Params["name"].(string)
 	if !ok {
-		s.sendError(w, req.ID, 400, "Nombre de herramienta requerido")
+		s.sendError(w, req.ID, 400, tr.T("server.tool_name_required"))
 		return
 	}
 
-	var result interface{}
-	var err error
+	// Sin 'lang' explícito se usa el idioma del cliente (Accept-Language)
+	if _, exists := params["lang"]; !exists {
+		params["lang"] = tr.Lang
+	}
+
+	// Se cancela si el cliente cierra la conexión o, con sesión SSE, con
+	// notifications/cancelled; el avance llega por la sesión SSE
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	if session := r.URL.Query().Get("session"); s.sessionOpen(session) {
+		key := session + "/" + req.ID
+		s.mu.Lock()
+		s.inflight[key] = cancel
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			delete(s.inflight, key)
+			s.mu.Unlock()
+		}()
 
-	switch toolName {
-	case "get_current_weather":
-		result, err = handlers.GetCurrentWeather(s.config, params)
-	case "get_forecast":
-		result, err = handlers.GetForecastSimple(s.config, params)
-	case "search_locations":
-		result, err = handlers.SearchLocations(s.config, params)
-	case "get_astronomy":
-		result, err = handlers.GetAstronomySimple(s.config, params)
-	default:
-		s.sendError(w, req.ID, 404, "Herramienta no encontrada")
+		if token := progressToken(req.Params); token != nil {
+			ctx = handlers.WithProgress(ctx, func(progress, total int, message string) {
+				s.notify(session, "notifications/progress", map[string]interface{}{
+					"progressToken": token,
+					"progress":      progress,
+					"total":         total,
+					"message":       message,
+				})
+			})
+		}
+	}
+
+	result, err := handlers.CallTool(ctx, s.config, toolName, params)
+	if ctx.Err() != nil {
+		// Cancelada: el cliente ya no espera la respuesta
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
+	if errors.Is(err, handlers.ErrToolNotFound) {
+		s.sendError(w, req.ID, 404, tr.T("server.tool_not_found", toolName))
+		return
+	}
+	if err != nil {
+		s.sendError(w, req.ID, 500, err.Error())
+		return
+	}
+
+	s.sendResponse(w, req.ID, handlers.BuildToolResponse(result, protocolVersion(r)))
+}
 
+// protocolVersion versión del protocolo de la solicitud: la cabecera
+// MCP-Protocol-Version o, sin ella, la que se supone por HTTP
+func protocolVersion(r *http.Request) string {
+	if version := r.Header.Get("MCP-Protocol-Version"); version != "" {
+		return handlers.NegotiateProtocol(version)
+	}
+	return handlers.ProtocolHTTPDefault
+}
+
+// progressToken token de avance de la solicitud (params._meta.progressToken); nil si
+// el cliente no lo pidió
+func progressToken(params map[string]interface{}) interface{} {
+	meta, _ := params["_meta"].(map[string]interface{})
+	return meta["progressToken"]
+}
+
+// handleCancelled cancela la herramienta en curso indicada en params.requestId de la
+// sesión SSE de la solicitud (?session=); las que ya terminaron se ignoran
+func (s *MCPServer) handleCancelled(w http.ResponseWriter, r *http.Request, req MCPRequest) {
+	key := r.URL.Query().Get("session") + "/" + fmt.Sprint(req.Params["requestId"])
+	s.mu.Lock()
+	cancel, ok := s.inflight[key]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sessionOpen indica si hay una conexión SSE abierta con ese id de sesión
+func (s *MCPServer) sessionOpen(session string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, open := s.sessions[session]
+	return open
+}
+
+// handleResourceRead lee un recurso por URI
+func (s *MCPServer) handleResourceRead(w http.ResponseWriter, r *http.Request, req MCPRequest) {
+	tr := s.translator(r)
+
+	uri, ok := req.Params["uri"].(string)
+	if !ok || uri == "" {
+		s.sendError(w, req.ID, 400, tr.T("server.uri_required"))
+		return
+	}
+
+	contents, err := handlers.ReadResource(r.Context(), s.config, tr, uri)
+	if errors.Is(err, handlers.ErrResourceNotFound) {
+		s.sendError(w, req.ID, 404, tr.T("server.resource_not_found", uri))
+		return
+	}
 	if err != nil {
 		s.sendError(w, req.ID, 500, err.Error())
 		return
 	}
 
-	s.sendResponse(w, req.ID, map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("%v", result),
-			},
-		},
+	s.sendResponse(w, req.ID, map[string]interface{}{"contents": contents})
+}
+
+// handleResourceSubscription suscribe o desuscribe la sesión SSE de la solicitud
+// (?session=) a un recurso; las notificaciones llegan por esa conexión
+func (s *MCPServer) handleResourceSubscription(w http.ResponseWriter, r *http.Request, req MCPRequest) {
+	tr := s.translator(r)
+
+	session := r.URL.Query().Get("session")
+	if !s.sessionOpen(session) {
+		s.sendError(w, req.ID, 400, tr.T("server.session_required"))
+		return
+	}
+
+	uri, ok := req.Params["uri"].(string)
+	if !ok || uri == "" {
+		s.sendError(w, req.ID, 400, tr.T("server.uri_required"))
+		return
+	}
+
+	if req.Method == "resources/unsubscribe" {
+		s.subscriptions.Unsubscribe(session, uri)
+		s.sendResponse(w, req.ID, map[string]interface{}{})
+		return
+	}
+
+	err := s.subscriptions.Subscribe(tr, session, uri)
+	if errors.Is(err, handlers.ErrResourceNotFound) {
+		s.sendError(w, req.ID, 404, tr.T("server.resource_not_found", uri))
+		return
+	}
+	if err != nil {
+		s.sendError(w, req.ID, 400, err.Error())
+		return
+	}
+	s.sendResponse(w, req.ID, map[string]interface{}{})
+}
+
+// handlePromptGet arma un prompt con sus argumentos
+func (s *MCPServer) handlePromptGet(w http.ResponseWriter, r *http.Request, req MCPRequest) {
+	tr := s.translator(r)
+
+	name, ok := req.Params["name"].(string)
+	if !ok || name == "" {
+		s.sendError(w, req.ID, 400, tr.T("server.prompt_name_required"))
+		return
+	}
+	arguments, _ := req.Params["arguments"].(map[string]interface{})
+
+	result, err := handlers.GetPrompt(tr, name, arguments)
+	if errors.Is(err, handlers.ErrPromptNotFound) {
+		s.sendError(w, req.ID, 404, tr.T("server.prompt_not_found", name))
+		return
+	}
+	if err != nil {
+		s.sendError(w, req.ID, 400, err.Error())
+		return
+	}
+	s.sendResponse(w, req.ID, result)
+}
+
+// handleCompletion sugiere valores para un argumento de un prompt o plantilla de recurso
+func (s *MCPServer) handleCompletion(w http.ResponseWriter, r *http.Request, req MCPRequest) {
+	tr := s.translator(r)
+
+	ref, _ := req.Params["ref"].(map[string]interface{})
+	argument, _ := req.Params["argument"].(map[string]interface{})
+	name, _ := argument["name"].(string)
+	if name == "" {
+		s.sendError(w, req.ID, 400, tr.T("server.completion_argument_required"))
+		return
+	}
+	value, _ := argument["value"].(string)
+
+	completion, err := handlers.Complete(r.Context(), s.config, tr, ref, name, value)
+	if err != nil {
+		s.sendError(w, req.ID, 404, tr.T("server.completion_ref_not_found"))
+		return
+	}
+	s.sendResponse(w, req.ID, map[string]interface{}{"completion": completion})
+}
+
+// handleSetLogLevel fija el nivel de los eventos de log que recibe la sesión SSE de la
+// solicitud (?session=)
+func (s *MCPServer) handleSetLogLevel(w http.ResponseWriter, r *http.Request, req MCPRequest) {
+	tr := s.translator(r)
+
+	session := r.URL.Query().Get("session")
+	if !s.sessionOpen(session) {
+		s.sendError(w, req.ID, 400, tr.T("server.session_required"))
+		return
+	}
+
+	level, ok := req.Params["level"].(string)
+	if !ok || level == "" {
+		s.sendError(w, req.ID, 400, tr.T("server.log_level_required"))
+		return
+	}
+	if err := handlers.SetLogLevel(tr, session, level); err != nil {
+		s.sendError(w, req.ID, 400, err.Error())
+		return
+	}
+	s.sendResponse(w, req.ID, map[string]interface{}{})
+}
+
+// handleSSE abre una conexión Server-Sent Events. El primer evento ('endpoint') indica
+// la URL con el id de sesión para enviar las solicitudes; después llegan las
+// notificaciones de los recursos suscritos y los eventos de log como eventos 'message'
+func (s *MCPServer) handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming no soportado", http.StatusInternalServerError)
+		return
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	session := hex.EncodeToString(id)
+	messages := make(chan []byte, 16)
+
+	s.mu.Lock()
+	s.sessions[session] = messages
+	s.mu.Unlock()
+	handlers.OpenLogSession(session, s.translator(r).Lang)
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, session)
+		s.mu.Unlock()
+		s.subscriptions.UnsubscribeAll(session)
+		handlers.CloseLogSession(session)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	fmt.Fprintf(w, "event: endpoint\ndata: /?session=%s\n\n", session)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case message := <-messages:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", message)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// notifyResourceUpdated envía notifications/resources/updated a una sesión SSE
+func (s *MCPServer) notifyResourceUpdated(session, uri string) {
+	s.notify(session, "notifications/resources/updated", map[string]interface{}{"uri": uri})
+}
+
+// notify envía una notificación JSON-RPC a una sesión SSE; si el cliente no lee y su
+// cola está llena, la notificación se descarta
+func (s *MCPServer) notify(session, method string, params interface{}) {
+	message, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
 	})
+
+	s.mu.Lock()
+	messages, ok := s.sessions[session]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case messages <- message:
+	default:
+	}
+}
+
+// translator elige el idioma de la solicitud: parámetro ?lang, cabecera
+// Accept-Language o el idioma por defecto de la configuración
+func (s *MCPServer) translator(r *http.Request) i18n.Translator {
+	if lang := i18n.Normalize(r.URL.Query().Get("lang")); lang != "" {
+		return i18n.For(lang)
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if lang := i18n.Normalize(tag); lang != "" {
+			return i18n.For(lang)
+		}
+	}
+	return i18n.For(s.config.Lang)
 }
 
 // listTools devuelve la lista de herramientas (endpoint GET)
 func (s *MCPServer) listTools(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(s.getToolDefinitions())
+	json.NewEncoder(w).Encode(s.getToolDefinitions(s.translator(r)))
 }
 
 // healthCheck endpoint de salud
@@ -169,90 +507,9 @@ func (s *MCPServer) healthCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 // getToolDefinitions devuelve las definiciones de todas las herramientas
-func (s *MCPServer) getToolDefinitions() map[string]interface{} {
+func (s *MCPServer) getToolDefinitions(tr i18n.Translator) map[string]interface{} {
 	return map[string]interface{}{
-		"tools": []ToolDefinition{
-			{
-				Name:        "get_current_weather",
-				Description: "Obtiene el clima actual para una ubicación específica",
-				InputSchema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"location": map[string]interface{}{
-							"type":        "string",
-							"description": "Nombre de la ciudad, código postal, coordenadas (lat,lon) o dirección IP",
-						},
-						"aqi": map[string]interface{}{
-							"type":        "string",
-							"description": "Incluir datos de calidad del aire (yes/no)",
-							"default":     "no",
-						},
-					},
-					"required": []string{"location"},
-				},
-			},
-			{
-				Name:        "get_forecast",
-				Description: "Obtiene el pronóstico del tiempo para una ubicación",
-				InputSchema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"location": map[string]interface{}{
-							"type":        "string",
-							"description": "Nombre de la ciudad, código postal, coordenadas (lat,lon) o dirección IP",
-						},
-						"days": map[string]interface{}{
-							"type":        "number",
-							"description": "Número de días de pronóstico (1-10)",
-							"default":     3,
-						},
-						"aqi": map[string]interface{}{
-							"type":        "string",
-							"description": "Incluir datos de calidad del aire (yes/no)",
-							"default":     "no",
-						},
-						"alerts": map[string]interface{}{
-							"type":        "string",
-							"description": "Incluir alertas meteorológicas (yes/no)",
-							"default":     "no",
-						},
-					},
-					"required": []string{"location"},
-				},
-			},
-			{
-				Name:        "search_locations",
-				Description: "Busca ubicaciones por nombre para obtener información detallada",
-				InputSchema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"query": map[string]interface{}{
-							"type":        "string",
-							"description": "Nombre de la ciudad o ubicación a buscar",
-						},
-					},
-					"required": []string{"query"},
-				},
-			},
-			{
-				Name:        "get_astronomy",
-				Description: "Obtiene datos astronómicos para una ubicación y fecha",
-				InputSchema: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"location": map[string]interface{}{
-							"type":        "string",
-							"description": "Nombre de la ciudad, código postal, coordenadas (lat,lon) o dirección IP",
-						},
-						"date": map[string]interface{}{
-							"type":        "string",
-							"description": "Fecha en formato YYYY-MM-DD (opcional, por defecto hoy)",
-						},
-					},
-					"required": []string{"location"},
-				},
-			},
-		},
+		"tools": handlers.ToolDefinitions(tr),
 	}
 }
 
@@ -2,13 +2,23 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"weather-mcp-server/config"
 	"weather-mcp-server/handlers"
+	"weather-mcp-server/i18n"
 )
 
 // MCPStdioRequest estructura de request MCP estándar
@@ -32,13 +42,100 @@ type MCPStdioError struct {
 	Message string `json:"message"`
 }
 
-// ToolDef define las herramientas disponibles
-type ToolDef struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
+// clientSession lo que el cliente indicó en initialize: el idioma ("" si no indicó
+// ninguno), la versión del protocolo negociada y si atiende sampling/createMessage y
+// elicitation/create
+type clientSession struct {
+	Lang        string
+	Protocol    string
+	Sampling    bool
+	Elicitation bool
 }
 
+// session datos de initialize; se escriben al inicializar y los leen los workers
+var (
+	sessionMu sync.RWMutex
+	session   clientSession
+)
+
+// currentSession copia de los datos de initialize
+func currentSession() clientSession {
+	sessionMu.RLock()
+	defer sessionMu.RUnlock()
+	return session
+}
+
+// stdioSession sesión única de las suscripciones y el log por stdio
+const stdioSession = "stdio"
+
+// subscriptions suscripciones a recursos; el sondeo notifica por stdout
+var subscriptions *handlers.Subscriptions
+
+// inflight cancelación de las solicitudes en curso, por id de solicitud
+var (
+	inflightMu sync.Mutex
+	inflight   = map[string]context.CancelFunc{}
+)
+
+const (
+	// maxStdioWorkers solicitudes atendidas a la vez; las demás esperan turno
+	maxStdioWorkers = 8
+	// maxStdioQueue solicitudes que pueden esperar turno; con la cola llena se
+	// rechazan las nuevas
+	maxStdioQueue = 64
+	// shutdownTimeout tiempo que se espera a las solicitudes en curso al cerrar; después
+	// se cancelan
+	shutdownTimeout = 10 * time.Second
+)
+
+// Solicitudes en curso o esperando turno, y cola de las que esperan
+var (
+	pending sync.WaitGroup
+	queue   = make(chan queuedCall, maxStdioQueue)
+)
+
+// queuedCall solicitud que espera un worker, con el contexto que la cancela y la
+// función que la da por terminada
+type queuedCall struct {
+	ctx  context.Context
+	done func()
+	req  MCPStdioRequest
+}
+
+// clientRequestTimeout tiempo que se espera la respuesta del cliente a una solicitud
+// del servidor (ej: sampling/createMessage, que depende del usuario y del modelo)
+const clientRequestTimeout = 2 * time.Minute
+
+// outgoing solicitudes del servidor al cliente que esperan respuesta, por id
+var (
+	outgoingMu sync.Mutex
+	outgoingID int
+	outgoing   = map[string]chan clientResponse{}
+)
+
+// clientGone se cierra al terminar stdin durante el cierre: el cliente ya no responderá
+var clientGone = make(chan struct{})
+
+// clientResponse respuesta del cliente a una solicitud del servidor
+type clientResponse struct {
+	ID     interface{}     `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *MCPStdioError  `json:"error"`
+}
+
+// Códigos de error de JSON-RPC para los mensajes que no se pudieron leer
+const (
+	parseErrorCode     = -32700
+	invalidRequestCode = -32600
+)
+
+// stdout encoder de los mensajes; stdoutMu serializa las escrituras entre respuestas y
+// notificaciones para que las líneas no se mezclen
+var (
+	stdoutMu sync.Mutex
+	stdout   = json.NewEncoder(os.Stdout)
+)
+
 func main() {
 	// Cargar configuración
 	cfg, err := config.LoadConfig()
@@ -46,200 +143,613 @@ func main() {
 		log.Fatalf("Error cargando configuración: %v", err)
 	}
 
-	scanner := bufio.NewScanner(os.Stdin)
+	stop := make(chan struct{})
+	subscriptions = handlers.NewSubscriptions(cfg, func(_, uri string) {
+		sendNotification("notifications/resources/updated", map[string]interface{}{"uri": uri})
+	})
+	go subscriptions.Run(stop)
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
+	handlers.SetLogNotify(func(_ string, message handlers.LogMessage) {
+		sendNotification("notifications/message", message)
+	})
+	handlers.OpenLogSession(stdioSession, cfg.Lang)
+
+	for i := 0; i < maxStdioWorkers; i++ {
+		go worker(cfg)
+	}
+
+	// stdin se lee en su propia goroutine para poder cerrar también con SIGTERM
+	messages := make(chan stdinMessage)
+	go readMessages(bufio.NewReader(os.Stdin), cfg.MaxMessageSize, messages)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+
+read:
+	for {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				break read
+			}
+			if req, ok := readRequest(cfg, message); ok {
+				dispatch(cfg, req)
+			}
+		case <-signals:
+			break read
 		}
+	}
 
-		var req MCPStdioRequest
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			sendError(req.ID, 400, "Request JSON inválido")
+	close(stop)
+	shutdown(cfg, messages)
+}
+
+// readRequest decodifica un mensaje de stdin. Los que no se pueden leer se responden
+// con un error, y las respuestas a solicitudes del servidor se entregan en el momento
+// (quien las espera ocupa un worker); en esos casos devuelve false
+func readRequest(cfg *config.Config, message stdinMessage) (MCPStdioRequest, bool) {
+	var req MCPStdioRequest
+	// Los mensajes ilegibles responden con id null: no se sabe a qué solicitud
+	// corresponden
+	if message.tooLarge {
+		sendError(nil, invalidRequestCode, translator(cfg).T("server.message_too_large", cfg.MaxMessageSize))
+		return req, false
+	}
+	if err := json.Unmarshal(message.data, &req); err != nil {
+		if !json.Valid(message.data) {
+			sendError(nil, parseErrorCode, translator(cfg).T("server.invalid_json"))
+			return req, false
+		}
+		// JSON válido con otra forma (ej: params que no es un objeto): se responde con
+		// su id si se puede leer
+		var envelope struct {
+			ID interface{} `json:"id"`
+		}
+		json.Unmarshal(message.data, &envelope)
+		sendError(envelope.ID, invalidRequestCode, translator(cfg).T("server.invalid_request"))
+		return req, false
+	}
+	if req.Method == "" && routeResponse(message.data) {
+		return req, false
+	}
+	return req, true
+}
+
+// stdinMessage mensaje leído de stdin; tooLarge si superó el tamaño máximo y se descartó
+type stdinMessage struct {
+	data     []byte
+	tooLarge bool
+}
+
+// readMessages lee los mensajes de stdin (un JSON por línea, sin límite de tamaño
+// propio) y los envía a messages; cierra messages al terminar stdin. Los mensajes de
+// más de maxSize bytes se descartan sin guardarlos y se informan con tooLarge
+func readMessages(reader *bufio.Reader, maxSize int, messages chan<- stdinMessage) {
+	defer close(messages)
+
+	var data []byte
+	tooLarge := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if !tooLarge {
+			if len(data)+len(chunk) > maxSize+2 { // +2 por el salto de línea "\r\n"
+				tooLarge, data = true, nil
+			} else {
+				data = append(data, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			// Línea más larga que el buffer: sigue en la próxima lectura
 			continue
 		}
+		// Con la línea completa el límite se aplica sin el salto de línea
+		if !tooLarge && len(bytes.TrimRight(data, "\r\n")) > maxSize {
+			tooLarge, data = true, nil
+		}
 
-		handleRequest(cfg, req)
+		if tooLarge {
+			messages <- stdinMessage{tooLarge: true}
+		} else if line := bytes.TrimSpace(data); len(line) > 0 {
+			messages <- stdinMessage{data: line}
+		}
+		data, tooLarge = nil, false
+
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Printf("Error leyendo stdin: %v", err)
+			return
+		}
+	}
+}
+
+// dispatch pone una solicitud en la cola de los workers. initialize (que fija el idioma
+// de las siguientes) y las notificaciones se atienden en el momento; la cancelación se
+// registra antes de seguir leyendo para que notifications/cancelled encuentre también
+// las solicitudes que esperan turno. Con la cola llena la solicitud se rechaza en vez
+// de esperar: la lectura de stdin no se detiene, así siguen llegando las respuestas
+// que esperan los workers
+func dispatch(cfg *config.Config, req MCPStdioRequest) {
+	if req.Method == "initialize" || strings.HasPrefix(req.Method, "notifications/") {
+		handleRequest(context.Background(), cfg, req)
+		return
+	}
+
+	ctx, done, ok := trackCall(req.ID)
+	if !ok {
+		sendError(req.ID, invalidRequestCode, translator(cfg).T("server.duplicate_id", fmt.Sprint(req.ID)))
+		return
 	}
+	pending.Add(1)
+	select {
+	case queue <- queuedCall{ctx: ctx, done: done, req: req}:
+	default:
+		done()
+		pending.Done()
+		sendError(req.ID, 503, translator(cfg).T("server.busy", maxStdioQueue))
+	}
+}
+
+// worker atiende las solicitudes de la cola hasta que se cierra
+func worker(cfg *config.Config) {
+	for call := range queue {
+		// Las canceladas mientras esperaban turno no se atienden
+		if call.ctx.Err() == nil {
+			handleRequest(call.ctx, cfg, call.req)
+		}
+		call.done()
+		pending.Done()
+	}
+}
+
+// shutdown espera a que terminen las solicitudes en curso; pasado shutdownTimeout
+// las cancela. Mientras espera sigue leyendo stdin: entrega las respuestas del cliente
+// que esperan los workers (ej: a elicitation/create), atiende las notificaciones y
+// rechaza las solicitudes nuevas. Cuando termina stdin ya no llegarán respuestas, y
+// las solicitudes al cliente fallan en el momento
+func shutdown(cfg *config.Config, messages <-chan stdinMessage) {
+	close(queue)
+	drained := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(drained)
+	}()
 
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error leyendo stdin: %v", err)
+	timeout := time.After(shutdownTimeout)
+	for {
+		select {
+		case <-drained:
+			return
+		case <-timeout:
+			inflightMu.Lock()
+			for _, cancel := range inflight {
+				cancel()
+			}
+			inflightMu.Unlock()
+			<-drained
+			return
+		case message, ok := <-messages:
+			if !ok {
+				close(clientGone)
+				messages = nil
+				continue
+			}
+			req, ok := readRequest(cfg, message)
+			switch {
+			case !ok:
+			case strings.HasPrefix(req.Method, "notifications/"):
+				handleRequest(context.Background(), cfg, req)
+			case req.ID != nil:
+				sendError(req.ID, 503, translator(cfg).T("server.shutting_down"))
+			}
+		}
 	}
 }
 
-func handleRequest(cfg *config.Config, req MCPStdioRequest) {
+func handleRequest(ctx context.Context, cfg *config.Config, req MCPStdioRequest) {
 	switch req.Method {
 	case "initialize":
-		handleInitialize(req)
+		handleInitialize(cfg, req)
 	case "tools/list":
-		handleToolsList(req)
+		handleToolsList(cfg, req)
 	case "tools/call":
-		handleToolCall(cfg, req)
+		handleToolCall(ctx, cfg, req)
+	case "notifications/cancelled":
+		handleCancelled(req)
+	case "resources/list":
+		sendResponse(req.ID, map[string]interface{}{
+			"resources": handlers.ListResources(cfg, translator(cfg)),
+		})
+	case "resources/templates/list":
+		sendResponse(req.ID, map[string]interface{}{
+			"resourceTemplates": handlers.ResourceTemplates(translator(cfg)),
+		})
+	case "resources/read":
+		handleResourceRead(ctx, cfg, req)
+	case "resources/subscribe", "resources/unsubscribe":
+		handleResourceSubscription(cfg, req)
+	case "prompts/list":
+		sendResponse(req.ID, map[string]interface{}{
+			"prompts": handlers.PromptDefinitions(translator(cfg)),
+		})
+	case "prompts/get":
+		handlePromptGet(cfg, req)
+	case "completion/complete":
+		handleCompletion(ctx, cfg, req)
+	case "logging/setLevel":
+		handleSetLogLevel(cfg, req)
 	default:
-		sendError(req.ID, 404, "Método no encontrado: "+req.Method)
+		sendError(req.ID, 404, translator(cfg).T("server.method_not_found", req.Method))
 	}
 }
 
-func handleInitialize(req MCPStdioRequest) {
-	result := map[string]interface{}{
-		"protocolVersion": "2024-11-05",
-		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{},
-		},
-		"serverInfo": map[string]interface{}{
-			"name":    "weather-mcp-server",
-			"version": "1.0.0",
-		},
+func handleInitialize(cfg *config.Config, req MCPStdioRequest) {
+	capabilities, _ := req.Params["capabilities"].(map[string]interface{})
+	requested, _ := req.Params["protocolVersion"].(string)
+	protocol := handlers.NegotiateProtocol(requested)
+
+	// elicitation/create no existe antes de 2025-06-18: con una versión anterior no se
+	// pregunta aunque el cliente anuncie la capacidad
+	sessionMu.Lock()
+	session = clientSession{
+		Lang:        initializeLang(req.Params),
+		Protocol:    protocol,
+		Sampling:    capabilities["sampling"] != nil,
+		Elicitation: capabilities["elicitation"] != nil && handlers.SupportsElicitation(protocol),
 	}
-	sendResponse(req.ID, result)
+	sessionMu.Unlock()
+	handlers.OpenLogSession(stdioSession, translator(cfg).Lang)
+
+	sendResponse(req.ID, handlers.InitializeResult(protocol))
 }
 
-func handleToolsList(req MCPStdioRequest) {
-	tools := []ToolDef{
-		{
-			Name:        "get_current_weather",
-			Description: "Obtiene el clima actual para una ubicación específica",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"location": map[string]interface{}{
-						"type":        "string",
-						"description": "Nombre de la ciudad, código postal, coordenadas (lat,lon) o dirección IP",
-					},
-					"aqi": map[string]interface{}{
-						"type":        "string",
-						"description": "Incluir datos de calidad del aire (yes/no)",
-						"default":     "no",
-					},
-				},
-				"required": []string{"location"},
-			},
-		},
-		{
-			Name:        "get_forecast",
-			Description: "Obtiene el pronóstico del tiempo para una ubicación",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"location": map[string]interface{}{
-						"type":        "string",
-						"description": "Nombre de la ciudad, código postal, coordenadas (lat,lon) o dirección IP",
-					},
-					"days": map[string]interface{}{
-						"type":        "number",
-						"description": "Número de días de pronóstico (1-10)",
-						"default":     3,
-					},
-				},
-				"required": []string{"location"},
-			},
-		},
-		{
-			Name:        "search_locations",
-			Description: "Busca ubicaciones por nombre para obtener información detallada",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"query": map[string]interface{}{
-						"type":        "string",
-						"description": "Nombre de la ciudad o ubicación a buscar",
-					},
-				},
-				"required": []string{"query"},
-			},
-		},
-		{
-			Name:        "get_astronomy",
-			Description: "Obtiene datos astronómicos para una ubicación y fecha",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"location": map[string]interface{}{
-						"type":        "string",
-						"description": "Nombre de la ciudad, código postal, coordenadas (lat,lon) o dirección IP",
-					},
-					"date": map[string]interface{}{
-						"type":        "string",
-						"description": "Fecha en formato YYYY-MM-DD (opcional, por defecto hoy)",
-					},
-				},
-				"required": []string{"location"},
-			},
-		},
+// initializeLang lee el idioma del cliente: params.locale o clientInfo.locale
+// (etiquetas BCP 47 como "en-US"); devuelve "" si no hay catálogo para ese idioma
+func initializeLang(params map[string]interface{}) string {
+	if locale, ok := params["locale"].(string); ok {
+		return i18n.Normalize(locale)
 	}
+	if info, ok := params["clientInfo"].(map[string]interface{}); ok {
+		if locale, ok := info["locale"].(string); ok {
+			return i18n.Normalize(locale)
+		}
+	}
+	return ""
+}
+
+// translator traductor de la sesión: idioma del cliente o el de la configuración
+func translator(cfg *config.Config) i18n.Translator {
+	if lang := currentSession().Lang; lang != "" {
+		return i18n.For(lang)
+	}
+	return i18n.For(cfg.Lang)
+}
 
+func handleToolsList(cfg *config.Config, req MCPStdioRequest) {
 	sendResponse(req.ID, map[string]interface{}{
-		"tools": tools,
+		"tools": handlers.ToolDefinitions(translator(cfg)),
 	})
 }
 
-func handleToolCall(cfg *config.Config, req MCPStdioRequest) {
+func handleToolCall(ctx context.Context, cfg *config.Config, req MCPStdioRequest) {
+	tr := translator(cfg)
+	client := currentSession()
+
 	params, ok := req.Params["arguments"].(map[string]interface{})
 	if !ok {
-		sendError(req.ID, 400, "Argumentos inválidos")
+		sendError(req.ID, 400, tr.T("server.invalid_arguments"))
 		return
 	}
 
 	toolName, ok := req.Params["name"].(string)
 	if !ok {
-		sendError(req.ID, 400, "Nombre de herramienta requerido")
+		sendError(req.ID, 400, tr.T("server.tool_name_required"))
 		return
 	}
 
-	var result interface{}
-	var err error
+	// Sin 'lang' explícito se usa el idioma de la sesión
+	if _, exists := params["lang"]; !exists && client.Lang != "" {
+		params["lang"] = client.Lang
+	}
 
-	switch toolName {
-	case "get_current_weather":
-		result, err = handlers.GetCurrentWeather(cfg, params)
-	case "get_forecast":
-		result, err = handlers.GetForecastSimple(cfg, params)
-	case "search_locations":
-		result, err = handlers.SearchLocations(cfg, params)
-	case "get_astronomy":
-		result, err = handlers.GetAstronomySimple(cfg, params)
-	default:
-		sendError(req.ID, 404, "Herramienta no encontrada")
+	if token := progressToken(req.Params); token != nil {
+		ctx = handlers.WithProgress(ctx, func(progress, total int, message string) {
+			sendNotification("notifications/progress", map[string]interface{}{
+				"progressToken": token,
+				"progress":      progress,
+				"total":         total,
+				"message":       message,
+			})
+		})
+	}
+
+	if client.Sampling {
+		ctx = handlers.WithSampler(ctx, sampleFromClient)
+	}
+	if client.Elicitation {
+		ctx = handlers.WithElicitor(ctx, elicitFromClient)
+	}
+
+	result, err := handlers.CallTool(ctx, cfg, toolName, params)
+	if ctx.Err() != nil {
+		// Cancelada por el cliente: no espera respuesta
+		return
+	}
+	if errors.Is(err, handlers.ErrToolNotFound) {
+		sendError(req.ID, 404, tr.T("server.tool_not_found", toolName))
+		return
+	}
+	if err != nil {
+		sendError(req.ID, 500, err.Error())
+		return
+	}
+
+	sendResponse(req.ID, handlers.BuildToolResponse(result, client.Protocol))
+}
+
+// trackCall registra una solicitud en curso para poder cancelarla con
+// notifications/cancelled; done la quita del registro. Devuelve false, sin registrarla,
+// si ya hay una en curso con el mismo id
+func trackCall(id interface{}) (context.Context, func(), bool) {
+	key := inflightKey(id)
+
+	inflightMu.Lock()
+	if _, exists := inflight[key]; exists {
+		inflightMu.Unlock()
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	inflight[key] = cancel
+	inflightMu.Unlock()
+
+	return ctx, func() {
+		inflightMu.Lock()
+		delete(inflight, key)
+		inflightMu.Unlock()
+		cancel()
+	}, true
+}
+
+// inflightKey clave de un id en el registro de solicitudes en curso; incluye el tipo
+// para que el id 1 y el id "1" sean solicitudes distintas
+func inflightKey(id interface{}) string {
+	return fmt.Sprintf("%T:%[1]v", id)
+}
+
+// progressToken token de avance de la solicitud (params._meta.progressToken); nil si
+// el cliente no lo pidió
+func progressToken(params map[string]interface{}) interface{} {
+	meta, _ := params["_meta"].(map[string]interface{})
+	return meta["progressToken"]
+}
+
+// handleCancelled cancela la solicitud en curso indicada en params.requestId; las
+// que ya terminaron se ignoran
+func handleCancelled(req MCPStdioRequest) {
+	key := inflightKey(req.Params["requestId"])
+	inflightMu.Lock()
+	cancel, ok := inflight[key]
+	inflightMu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func handleResourceRead(ctx context.Context, cfg *config.Config, req MCPStdioRequest) {
+	tr := translator(cfg)
+
+	uri, ok := req.Params["uri"].(string)
+	if !ok || uri == "" {
+		sendError(req.ID, 400, tr.T("server.uri_required"))
 		return
 	}
 
+	contents, err := handlers.ReadResource(ctx, cfg, tr, uri)
+	if errors.Is(err, handlers.ErrResourceNotFound) {
+		sendError(req.ID, 404, tr.T("server.resource_not_found", uri))
+		return
+	}
 	if err != nil {
 		sendError(req.ID, 500, err.Error())
 		return
 	}
 
-	sendResponse(req.ID, map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("%v", result),
-			},
-		},
+	sendResponse(req.ID, map[string]interface{}{"contents": contents})
+}
+
+func handleResourceSubscription(cfg *config.Config, req MCPStdioRequest) {
+	tr := translator(cfg)
+
+	uri, ok := req.Params["uri"].(string)
+	if !ok || uri == "" {
+		sendError(req.ID, 400, tr.T("server.uri_required"))
+		return
+	}
+
+	if req.Method == "resources/unsubscribe" {
+		subscriptions.Unsubscribe(stdioSession, uri)
+		sendResponse(req.ID, map[string]interface{}{})
+		return
+	}
+
+	err := subscriptions.Subscribe(tr, stdioSession, uri)
+	if errors.Is(err, handlers.ErrResourceNotFound) {
+		sendError(req.ID, 404, tr.T("server.resource_not_found", uri))
+		return
+	}
+	if err != nil {
+		sendError(req.ID, 400, err.Error())
+		return
+	}
+	sendResponse(req.ID, map[string]interface{}{})
+}
+
+func handlePromptGet(cfg *config.Config, req MCPStdioRequest) {
+	tr := translator(cfg)
+
+	name, ok := req.Params["name"].(string)
+	if !ok || name == "" {
+		sendError(req.ID, 400, tr.T("server.prompt_name_required"))
+		return
+	}
+	arguments, _ := req.Params["arguments"].(map[string]interface{})
+
+	result, err := handlers.GetPrompt(tr, name, arguments)
+	if errors.Is(err, handlers.ErrPromptNotFound) {
+		sendError(req.ID, 404, tr.T("server.prompt_not_found", name))
+		return
+	}
+	if err != nil {
+		sendError(req.ID, 400, err.Error())
+		return
+	}
+	sendResponse(req.ID, result)
+}
+
+func handleCompletion(ctx context.Context, cfg *config.Config, req MCPStdioRequest) {
+	tr := translator(cfg)
+
+	ref, _ := req.Params["ref"].(map[string]interface{})
+	argument, _ := req.Params["argument"].(map[string]interface{})
+	name, _ := argument["name"].(string)
+	if name == "" {
+		sendError(req.ID, 400, tr.T("server.completion_argument_required"))
+		return
+	}
+	value, _ := argument["value"].(string)
+
+	completion, err := handlers.Complete(ctx, cfg, tr, ref, name, value)
+	if err != nil {
+		sendError(req.ID, 404, tr.T("server.completion_ref_not_found"))
+		return
+	}
+	sendResponse(req.ID, map[string]interface{}{"completion": completion})
+}
+
+func handleSetLogLevel(cfg *config.Config, req MCPStdioRequest) {
+	tr := translator(cfg)
+
+	level, ok := req.Params["level"].(string)
+	if !ok || level == "" {
+		sendError(req.ID, 400, tr.T("server.log_level_required"))
+		return
+	}
+	if err := handlers.SetLogLevel(tr, stdioSession, level); err != nil {
+		sendError(req.ID, 400, err.Error())
+		return
+	}
+	sendResponse(req.ID, map[string]interface{}{})
+}
+
+// sampleFromClient pide texto al modelo del cliente con sampling/createMessage
+func sampleFromClient(ctx context.Context, request handlers.SamplingRequest) (*handlers.SamplingResult, error) {
+	var result handlers.SamplingResult
+	if err := sendRequest(ctx, "sampling/createMessage", request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// elicitFromClient pregunta al usuario con elicitation/create
+func elicitFromClient(ctx context.Context, request handlers.ElicitRequest) (*handlers.ElicitResult, error) {
+	var result handlers.ElicitResult
+	if err := sendRequest(ctx, "elicitation/create", request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// sendRequest envía una solicitud al cliente y espera su respuesta (hasta
+// clientRequestTimeout, o hasta que termine stdin al cerrar); el resultado se decodifica
+// en out. Si ctx se cancela antes, avisa al cliente con notifications/cancelled
+func sendRequest(ctx context.Context, method string, params interface{}, out interface{}) error {
+	outgoingMu.Lock()
+	outgoingID++
+	id := fmt.Sprintf("server-%d", outgoingID)
+	reply := make(chan clientResponse, 1)
+	outgoing[id] = reply
+	outgoingMu.Unlock()
+
+	defer func() {
+		outgoingMu.Lock()
+		delete(outgoing, id)
+		outgoingMu.Unlock()
+	}()
+
+	writeMessage(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
 	})
+
+	wait, cancel := context.WithTimeout(ctx, clientRequestTimeout)
+	defer cancel()
+	select {
+	case resp := <-reply:
+		if resp.Error != nil {
+			return fmt.Errorf("%s: %s", method, resp.Error.Message)
+		}
+		return json.Unmarshal(resp.Result, out)
+	case <-wait.Done():
+		sendNotification("notifications/cancelled", map[string]interface{}{"requestId": id})
+		return fmt.Errorf("%s: %w", method, wait.Err())
+	case <-clientGone:
+		return fmt.Errorf("%s: %w", method, io.ErrClosedPipe)
+	}
+}
+
+// routeResponse entrega un mensaje de stdin a la solicitud del servidor que espera esa
+// respuesta; devuelve false si no es una respuesta (no tiene result ni error). Las
+// respuestas que ya nadie espera se descartan
+func routeResponse(data []byte) bool {
+	var resp clientResponse
+	if err := json.Unmarshal(data, &resp); err != nil || (resp.Result == nil && resp.Error == nil) {
+		return false
+	}
+
+	outgoingMu.Lock()
+	reply, ok := outgoing[fmt.Sprint(resp.ID)]
+	delete(outgoing, fmt.Sprint(resp.ID))
+	outgoingMu.Unlock()
+	if ok {
+		reply <- resp
+	}
+	return true
 }
 
 func sendResponse(id interface{}, result interface{}) {
-	response := MCPStdioResponse{
+	writeMessage(MCPStdioResponse{
 		Jsonrpc: "2.0",
 		ID:      id,
 		Result:  result,
-	}
-
-	jsonData, _ := json.Marshal(response)
-	fmt.Println(string(jsonData))
+	})
 }
 
 func sendError(id interface{}, code int, message string) {
-	response := MCPStdioResponse{
+	writeMessage(MCPStdioResponse{
 		Jsonrpc: "2.0",
 		ID:      id,
 		Error: &MCPStdioError{
 			Code:    code,
 			Message: message,
 		},
-	}
+	})
+}
+
+// sendNotification envía una notificación JSON-RPC (sin id) al cliente
+func sendNotification(method string, params interface{}) {
+	writeMessage(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
 
-	jsonData, _ := json.Marshal(response)
-	fmt.Println(string(jsonData))
+// writeMessage escribe un mensaje JSON por línea en stdout
+func writeMessage(message interface{}) {
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+	stdout.Encode(message)
 }
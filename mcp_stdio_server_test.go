@@ -0,0 +1,209 @@
+package main
+
+// El paquete raíz tiene dos main (HTTP y stdio): estas pruebas se ejecutan con
+// go test mcp_stdio_server.go mcp_stdio_server_test.go
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-mcp-server/config"
+)
+
+func TestReadMessages(t *testing.T) {
+	long := `{"jsonrpc":"2.0","id":9,"method":"tools/list","params":{"pad":"` + strings.Repeat("x", 200) + `"}}`
+	tests := []struct {
+		name  string
+		input string
+		want  []string // "!" es un mensaje descartado por tamaño
+	}{
+		{"un mensaje por línea", "{\"id\":1}\n{\"id\":2}\n", []string{`{"id":1}`, `{"id":2}`}},
+		{"sin salto de línea final", `{"id":1}`, []string{`{"id":1}`}},
+		{"líneas vacías y espacios", "\n  \r\n {\"id\":1} \r\n\n", []string{`{"id":1}`}},
+		{"CRLF", "{\"id\":1}\r\n", []string{`{"id":1}`}},
+		{"demasiado grande", long + "\n{\"id\":2}\n", []string{"!", `{"id":2}`}},
+		{"demasiado grande al final", "{\"id\":1}\n" + long, []string{`{"id":1}`, "!"}},
+		{"justo en el límite", strings.Repeat("a", 64) + "\n", []string{strings.Repeat("a", 64)}},
+		{"un byte más del límite", strings.Repeat("a", 65) + "\n", []string{"!"}},
+		{"CRLF justo en el límite", strings.Repeat("a", 64) + "\r\n", []string{strings.Repeat("a", 64)}},
+		{"CRLF un byte más del límite", strings.Repeat("a", 65) + "\r\n", []string{"!"}},
+		{"un byte más del límite sin salto de línea", strings.Repeat("a", 65), []string{"!"}},
+	}
+	for _, tt := range tests {
+		// Un buffer de lectura chico obliga a armar las líneas largas en varias lecturas
+		reader := bufio.NewReaderSize(strings.NewReader(tt.input), 16)
+		messages := make(chan stdinMessage, 10)
+		readMessages(reader, 64, messages)
+
+		var got []string
+		for message := range messages {
+			if message.tooLarge {
+				got = append(got, "!")
+			} else {
+				got = append(got, string(message.data))
+			}
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: %q, se esperaba %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRouteResponse(t *testing.T) {
+	reply := make(chan clientResponse, 1)
+	outgoingMu.Lock()
+	outgoing["server-1"] = reply
+	outgoingMu.Unlock()
+
+	tests := []struct {
+		data     string
+		response bool
+	}{
+		{`{"jsonrpc":"2.0","id":"server-1","result":{"action":"accept"}}`, true},
+		// Respuesta que ya nadie espera: se descarta
+		{`{"jsonrpc":"2.0","id":"server-1","result":{}}`, true},
+		{`{"jsonrpc":"2.0","id":"server-2","error":{"code":-1,"message":"no"}}`, true},
+		{`{"jsonrpc":"2.0","id":3}`, false},
+		{`{"jsonrpc":"2.0","id":3,"method":"tools/list"}`, false},
+	}
+	for _, tt := range tests {
+		if got := routeResponse([]byte(tt.data)); got != tt.response {
+			t.Errorf("routeResponse(%s) = %v, se esperaba %v", tt.data, got, tt.response)
+		}
+	}
+
+	select {
+	case resp := <-reply:
+		if string(resp.Result) != `{"action":"accept"}` {
+			t.Errorf("resultado entregado: %s", resp.Result)
+		}
+	default:
+		t.Errorf("la respuesta no llegó a quien la esperaba")
+	}
+	if len(reply) != 0 {
+		t.Errorf("se entregó más de una respuesta")
+	}
+}
+
+// captureStdout redirige los mensajes del servidor a un buffer mientras dura la prueba
+func captureStdout(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	stdoutMu.Lock()
+	previous := stdout
+	stdout = json.NewEncoder(&buf)
+	stdoutMu.Unlock()
+	t.Cleanup(func() {
+		stdoutMu.Lock()
+		stdout = previous
+		stdoutMu.Unlock()
+	})
+	return &buf
+}
+
+func TestDispatchQueueFull(t *testing.T) {
+	buf := captureStdout(t)
+	cfg := &config.Config{Lang: "es"}
+
+	// Sin workers la cola se llena y la siguiente solicitud se rechaza en el momento
+	for i := 0; i < maxStdioQueue; i++ {
+		dispatch(cfg, MCPStdioRequest{ID: float64(i), Method: "tools/list"})
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("se respondió antes de llenar la cola: %s", buf)
+	}
+	dispatch(cfg, MCPStdioRequest{ID: "extra", Method: "tools/list"})
+
+	var resp MCPStdioResponse
+	if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+		t.Fatalf("respuesta ilegible %q: %v", buf, err)
+	}
+	if resp.ID != "extra" || resp.Error == nil || resp.Error.Code != 503 {
+		t.Errorf("respuesta = %+v, se esperaba un error 503 para extra", resp)
+	}
+
+	// Vaciar la cola: las solicitudes encoladas quedan pendientes hasta atenderse
+	for i := 0; i < maxStdioQueue; i++ {
+		call := <-queue
+		call.done()
+		pending.Done()
+	}
+	waitPending(t)
+}
+
+func TestDispatchDuplicateID(t *testing.T) {
+	buf := captureStdout(t)
+	cfg := &config.Config{Lang: "es"}
+
+	// El id 1 y el id "1" son solicitudes distintas; repetir uno en curso es inválido
+	dispatch(cfg, MCPStdioRequest{ID: float64(1), Method: "tools/list"})
+	dispatch(cfg, MCPStdioRequest{ID: "1", Method: "tools/list"})
+	if buf.Len() != 0 {
+		t.Fatalf("se rechazó una solicitud con id de otro tipo: %s", buf)
+	}
+	dispatch(cfg, MCPStdioRequest{ID: float64(1), Method: "tools/list"})
+
+	var resp MCPStdioResponse
+	if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+		t.Fatalf("respuesta ilegible %q: %v", buf, err)
+	}
+	if resp.ID != float64(1) || resp.Error == nil || resp.Error.Code != invalidRequestCode {
+		t.Errorf("respuesta = %+v, se esperaba un error %d para el id 1", resp, invalidRequestCode)
+	}
+
+	// La cancelación distingue el tipo del id
+	handleCancelled(MCPStdioRequest{Method: "notifications/cancelled", Params: map[string]interface{}{"requestId": "1"}})
+	first, second := <-queue, <-queue
+	if first.ctx.Err() != nil || second.ctx.Err() == nil {
+		t.Errorf("se esperaba cancelado solo el id \"1\": 1 = %v, \"1\" = %v", first.ctx.Err(), second.ctx.Err())
+	}
+	for _, call := range []queuedCall{first, second} {
+		call.done()
+		pending.Done()
+	}
+	waitPending(t)
+}
+
+// waitPending falla si quedaron solicitudes pendientes sin terminar
+func waitPending(t *testing.T) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("quedaron solicitudes pendientes")
+	}
+}
+
+// TestSendRequestClientGone cierra clientGone, que no se vuelve a abrir: tiene que ser
+// la última prueba que usa sendRequest
+func TestSendRequestClientGone(t *testing.T) {
+	captureStdout(t)
+	close(clientGone)
+
+	start := time.Now()
+	var out map[string]interface{}
+	err := sendRequest(context.Background(), "elicitation/create", map[string]interface{}{}, &out)
+	if !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("err = %v, se esperaba io.ErrClosedPipe", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("sendRequest tardó %s con stdin cerrado", elapsed)
+	}
+	outgoingMu.Lock()
+	defer outgoingMu.Unlock()
+	if len(outgoing) != 0 {
+		t.Errorf("quedaron solicitudes al cliente registradas: %v", outgoing)
+	}
+}
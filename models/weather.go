@@ -11,6 +11,22 @@ type ForecastResponse struct {
 	Location LocationInfo `json:"location"`
 	Current  CurrentInfo  `json:"current"`
 	Forecast ForecastInfo `json:"forecast"`
+	Alerts   AlertsInfo   `json:"alerts"` // solo con alerts=yes
+}
+
+// AlertsInfo alertas meteorológicas vigentes
+type AlertsInfo struct {
+	Alert []Alert `json:"alert"`
+}
+
+// Alert alerta meteorológica emitida por el servicio nacional
+type Alert struct {
+	Headline  string `json:"headline"`
+	Severity  string `json:"severity"`
+	Event     string `json:"event"`
+	Effective string `json:"effective"`
+	Expires   string `json:"expires"`
+	Desc      string `json:"desc"`
 }
 
 // SearchLocationResponse respuesta de búsqueda de ubicaciones
@@ -116,12 +132,12 @@ type DayInfo struct {
 
 // AstroInfo información astronómica del día
 type AstroInfo struct {
-	Sunrise          string `json:"sunrise"`
-	Sunset           string `json:"sunset"`
-	Moonrise         string `json:"moonrise"`
-	Moonset          string `json:"moonset"`
-	MoonPhase        string `json:"moon_phase"`
-	MoonIllumination string `json:"moon_illumination"`
+	Sunrise          string      `json:"sunrise"`
+	Sunset           string      `json:"sunset"`
+	Moonrise         string      `json:"moonrise"`
+	Moonset          string      `json:"moonset"`
+	MoonPhase        string      `json:"moon_phase"`
+	MoonIllumination interface{} `json:"moon_illumination"` // Puede ser string o number
 }
 
 // HourInfo información por hora
@@ -0,0 +1,102 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Formatos de salida disponibles
+const (
+	FormatText     = "text"     // texto con emojis (por defecto)
+	FormatMarkdown = "markdown" // encabezados, listas y tablas markdown
+	FormatPlain    = "plain"    // texto sin emojis
+	FormatCompact  = "compact"  // resumen de una línea
+	FormatJSON     = "json"     // resultado estructurado como JSON
+)
+
+// Formats devuelve los formatos disponibles
+func Formats() []string {
+	return []string{FormatText, FormatMarkdown, FormatPlain, FormatCompact, FormatJSON}
+}
+
+// Valid indica si el formato existe
+func Valid(format string) bool {
+	for _, f := range Formats() {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Document representación neutral de la respuesta de una herramienta. Los handlers
+// la arman a partir de sus datos y cada formato la presenta a su manera
+type Document struct {
+	Icon     string
+	Title    string
+	Summary  string // resumen de una línea para el formato compact
+	Fields   []Field
+	Sections []Section
+	Notes    []Field // avisos y aclaraciones al final
+}
+
+// Field par etiqueta/valor; sin etiqueta se muestra solo el valor
+type Field struct {
+	Icon  string
+	Label string
+	Value string
+}
+
+// Section bloque con título que puede tener campos, una tabla y una lista.
+// Los elementos de la lista pueden tener varias líneas (separadas por "\n")
+type Section struct {
+	Icon    string
+	Title   string
+	Fields  []Field
+	Table   *Table
+	List    []string
+	Ordered bool
+}
+
+// Table tabla con encabezados
+type Table struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// Render presenta el documento en el formato indicado; el formato json usa data.
+// Los demás formatos usan la plantilla propia de name si existe, o la general
+func Render(format, name, lang string, doc Document, data interface{}) (string, error) {
+	if format == FormatJSON {
+		if data == nil {
+			data = doc
+		}
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+	if !Valid(format) {
+		return "", fmt.Errorf("formato desconocido: %s", format)
+	}
+	if data == nil {
+		data = doc
+	}
+	return renderTemplate(format, name, lang, doc, data)
+}
+
+// markdownEscape escapa los caracteres que romperían una tabla o el énfasis markdown
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "*", "\\*", "_", "\\_").Replace(s)
+}
+
+// escapeCells escapa cada celda de una fila
+func escapeCells(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = markdownEscape(cell)
+	}
+	return escaped
+}
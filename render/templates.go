@@ -0,0 +1,266 @@
+package render
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+
+	"weather-mcp-server/i18n"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+var (
+	mu        sync.RWMutex
+	templates *template.Template
+	results   = map[string]reflect.Type{}
+)
+
+// View datos que recibe cada plantilla: el documento neutral, el resultado
+// estructurado de la herramienta y el idioma de la respuesta
+type View struct {
+	Lang  string
+	Icons bool // false en el formato plain
+	Doc   Document
+	Data  interface{}
+}
+
+func init() {
+	t, err := parseTemplates("")
+	if err != nil {
+		panic(err)
+	}
+	templates = t
+}
+
+// RegisterResult asocia un nombre de resultado (normalmente el de la herramienta)
+// con el tipo de su resultado estructurado; las plantillas propias
+// "<nombre>.<formato>.tmpl" se validan contra ese tipo al cargarlas
+func RegisterResult(name string, sample interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	results[name] = reflect.TypeOf(sample)
+}
+
+// LoadDir reemplaza las plantillas incluidas por las de dir y valida todas contra
+// los tipos registrados, de modo que una plantilla rota falle al iniciar.
+// Los archivos admitidos son "<formato>.tmpl" (para todas las herramientas) y
+// "<nombre>.<formato>.tmpl" (para un resultado registrado)
+func LoadDir(dir string) error {
+	t, err := parseTemplates(dir)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err := validateTemplates(t); err != nil {
+		return err
+	}
+	templates = t
+	return nil
+}
+
+// parseTemplates arma el conjunto de plantillas: primero las incluidas y luego las de dir
+func parseTemplates(dir string) (*template.Template, error) {
+	t := template.New("").Funcs(templateFuncs()).Option("missingkey=error")
+	t, err := t.ParseFS(builtinTemplates, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return t, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		name := filepath.Base(file)
+		if err := checkTemplateName(name); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo leer %s: %v", file, err)
+		}
+		if _, err := t.New(name).Parse(string(data)); err != nil {
+			return nil, fmt.Errorf("plantilla inválida %s: %v", file, err)
+		}
+	}
+	return t, nil
+}
+
+// checkTemplateName verifica que el archivo corresponda a un formato y a un resultado conocidos
+func checkTemplateName(name string) error {
+	parts := strings.Split(strings.TrimSuffix(name, ".tmpl"), ".")
+	format := parts[len(parts)-1]
+	if !templated(format) {
+		return fmt.Errorf("formato sin plantilla: %s (disponibles: %s)", format, strings.Join(templatedFormats(), ", "))
+	}
+	switch len(parts) {
+	case 1:
+		return nil
+	case 2:
+		mu.RLock()
+		_, ok := results[parts[0]]
+		mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("resultado desconocido: %s (disponibles: %s)", parts[0], strings.Join(resultNames(), ", "))
+		}
+		return nil
+	}
+	return fmt.Errorf("nombre inválido, use <formato>.tmpl o <herramienta>.<formato>.tmpl")
+}
+
+// validateTemplates ejecuta cada plantilla con datos de muestra de cada tipo registrado.
+// Los datos de muestra tienen un elemento en cada lista y los punteros asignados, así
+// que un campo inexistente falla aunque esté dentro de un range o un if. Las claves de
+// los mapas no se conocen hasta tener datos reales: al validar, una clave que falta da
+// el valor cero en vez de un error
+func validateTemplates(t *template.Template) error {
+	t, err := t.Clone()
+	if err != nil {
+		return err
+	}
+	t.Option("missingkey=zero")
+
+	doc := sampleValue(reflect.TypeOf(Document{}), 0).Interface().(Document)
+	for _, format := range templatedFormats() {
+		for name, typ := range results {
+			view := View{Lang: i18n.DefaultLang, Icons: true, Doc: doc, Data: sampleValue(typ, 0).Interface()}
+
+			tmplName := format + ".tmpl"
+			if own := t.Lookup(name + "." + tmplName); own != nil {
+				tmplName = own.Name()
+			}
+			if err := t.ExecuteTemplate(io.Discard, tmplName, view); err != nil {
+				return fmt.Errorf("plantilla %s inválida para %s: %v", tmplName, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sampleValue arma un valor de muestra del tipo: listas y mapas con un elemento y punteros asignados
+func sampleValue(t reflect.Type, depth int) reflect.Value {
+	v := reflect.New(t).Elem()
+	if depth > 8 {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(t.Elem())
+		p.Elem().Set(sampleValue(t.Elem(), depth+1))
+		v.Set(p)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if field := v.Field(i); field.CanSet() {
+				field.Set(sampleValue(t.Field(i).Type, depth+1))
+			}
+		}
+	case reflect.Slice:
+		v.Set(reflect.Append(reflect.MakeSlice(t, 0, 1), sampleValue(t.Elem(), depth+1)))
+	case reflect.Map:
+		m := reflect.MakeMap(t)
+		m.SetMapIndex(reflect.New(t.Key()).Elem(), sampleValue(t.Elem(), depth+1))
+		v.Set(m)
+	}
+	return v
+}
+
+// renderTemplate presenta el resultado con la plantilla propia de name si existe,
+// o con la general del formato
+func renderTemplate(format, name, lang string, doc Document, data interface{}) (string, error) {
+	mu.RLock()
+	t := templates
+	mu.RUnlock()
+
+	tmplName := format + ".tmpl"
+	if name != "" && t.Lookup(name+"."+tmplName) != nil {
+		tmplName = name + "." + tmplName
+	}
+
+	var buf bytes.Buffer
+	view := View{Lang: lang, Icons: format != FormatPlain, Doc: doc, Data: data}
+	if err := t.ExecuteTemplate(&buf, tmplName, view); err != nil {
+		return "", fmt.Errorf("error en la plantilla %s: %v", tmplName, err)
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// templated indica si el formato se presenta con plantillas (todos salvo json)
+func templated(format string) bool {
+	return format != FormatJSON && Valid(format)
+}
+
+// templatedFormats formatos que se presentan con plantillas
+func templatedFormats() []string {
+	var formats []string
+	for _, f := range Formats() {
+		if templated(f) {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
+// resultNames nombres de resultado registrados, ordenados
+func resultNames() []string {
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateFuncs funciones disponibles en las plantillas
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"join":    strings.Join,
+		"repeat":  strings.Repeat,
+		"lines":   func(s string) []string { return strings.Split(s, "\n") },
+		"add":     func(a, b int) int { return a + b },
+		"md":      markdownEscape,
+		"mdcells": escapeCells,
+		"table":   formatTable,
+		// icon devuelve "icono " si se usan emojis y el icono existe, si no fallback
+		"icon": func(icons bool, icon, fallback string) string {
+			if icons && icon != "" {
+				return icon + " "
+			}
+			return fallback
+		},
+		// t traduce una clave del catálogo: {{t .Lang "label.temperature"}}
+		"t": func(lang, key string, args ...interface{}) string {
+			return i18n.For(lang).T(key, args...)
+		},
+	}
+}
+
+// formatTable devuelve la tabla alineada por columnas
+func formatTable(t *Table) string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.Columns, "\t"))
+	for _, row := range t.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+	return buf.String()
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sampleResult resultado con un mapa, como las métricas de compare_weather
+type sampleResult struct {
+	Name    string
+	Metrics map[string]float64
+	Rows    []struct{ Metrics map[string]float64 }
+}
+
+func TestLoadDirValidation(t *testing.T) {
+	RegisterResult("sample_tool", sampleResult{})
+	defer LoadDir("")
+
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{`{{.Data.Name}}`, ""},
+		{`{{.Data.Metrics.temp_c}}`, ""},
+		{`{{index .Data.Metrics "temp_c"}}`, ""},
+		{`{{range .Data.Rows}}{{.Metrics.wind_kph}}{{end}}`, ""},
+		{`{{range .Doc.Fields}}{{.Label}}{{end}}`, ""},
+		{`{{.Data.Nombre}}`, "Nombre"},
+		{`{{range .Data.Rows}}{{.Metricas}}{{end}}`, "Metricas"},
+		{`{{.Data.Name`, "plantilla inválida"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "sample_tool.text.tmpl"), []byte(tt.template), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		err := LoadDir(dir)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.template, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: error %v, se esperaba uno con %q", tt.template, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadDirNames(t *testing.T) {
+	defer LoadDir("")
+	for _, name := range []string{"json.tmpl", "unknown_tool.text.tmpl", "a.b.text.tmpl"} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := LoadDir(dir); err == nil {
+			t.Errorf("%s: se esperaba un error por el nombre", name)
+		}
+	}
+}
+
+func TestRenderMissingMapKey(t *testing.T) {
+	RegisterResult("sample_tool", sampleResult{})
+	defer LoadDir("")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sample_tool.text.tmpl"), []byte(`{{.Data.Metrics.temp_c}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := renderTemplate(FormatText, "sample_tool", "es", Document{}, sampleResult{Metrics: map[string]float64{"temp_c": 21.5}})
+	if err != nil || got != "21.5" {
+		t.Errorf("renderTemplate = %q, %v; se esperaba 21.5", got, err)
+	}
+	// Al responder, una clave que falta sigue siendo un error
+	if _, err := renderTemplate(FormatText, "sample_tool", "es", Document{}, sampleResult{Metrics: map[string]float64{}}); err == nil {
+		t.Errorf("se esperaba un error por la clave que falta")
+	}
+}
@@ -0,0 +1,128 @@
+package units
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// System sistema de unidades usado para presentar las mediciones.
+// Los datos internos siempre están en métrico (°C, km/h, mb, mm, km) y se convierten al mostrar
+type System struct {
+	Name        string `json:"system"`
+	Temperature string `json:"temperature"`
+	Speed       string `json:"speed"`
+	Pressure    string `json:"pressure"`
+	Precip      string `json:"precipitation"`
+	Distance    string `json:"distance"`
+}
+
+// presets sistemas predefinidos
+var presets = map[string]System{
+	"metric":   {Name: "metric", Temperature: "°C", Speed: "km/h", Pressure: "mb", Precip: "mm", Distance: "km"},
+	"imperial": {Name: "imperial", Temperature: "°F", Speed: "mph", Pressure: "inHg", Precip: "in", Distance: "mi"},
+	"si":       {Name: "si", Temperature: "K", Speed: "m/s", Pressure: "hPa", Precip: "mm", Distance: "km"},
+	"uk":       {Name: "uk", Temperature: "°C", Speed: "mph", Pressure: "mb", Precip: "mm", Distance: "mi"},
+}
+
+// Metric sistema por defecto
+var Metric = presets["metric"]
+
+// Parse devuelve el sistema con ese nombre (metric, imperial, si, uk)
+func Parse(name string) (System, error) {
+	system, ok := presets[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return System{}, fmt.Errorf("sistema de unidades desconocido: %s (disponibles: %s)", name, strings.Join(Names(), ", "))
+	}
+	return system, nil
+}
+
+// Names devuelve los nombres de los sistemas disponibles
+func Names() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Temp convierte una temperatura en °C
+func (s System) Temp(c float64) float64 {
+	switch s.Temperature {
+	case "°F":
+		return c*9/5 + 32
+	case "K":
+		return c + 273.15
+	}
+	return c
+}
+
+// SpeedValue convierte una velocidad en km/h
+func (s System) SpeedValue(kph float64) float64 {
+	switch s.Speed {
+	case "mph":
+		return kph / 1.609344
+	case "m/s":
+		return kph / 3.6
+	}
+	return kph
+}
+
+// PressureValue convierte una presión en mb
+func (s System) PressureValue(mb float64) float64 {
+	if s.Pressure == "inHg" {
+		return mb * 0.0295299830714
+	}
+	return mb
+}
+
+// PrecipValue convierte una precipitación en mm
+func (s System) PrecipValue(mm float64) float64 {
+	if s.Precip == "in" {
+		return mm / 25.4
+	}
+	return mm
+}
+
+// DistanceValue convierte una distancia en km
+func (s System) DistanceValue(km float64) float64 {
+	if s.Distance == "mi" {
+		return km / 1.609344
+	}
+	return km
+}
+
+// FormatTemp formatea una temperatura en °C con su unidad
+func (s System) FormatTemp(c float64) string {
+	if s.Temperature == "K" {
+		return fmt.Sprintf("%.1f K", s.Temp(c))
+	}
+	return fmt.Sprintf("%.1f%s", s.Temp(c), s.Temperature)
+}
+
+// FormatSpeed formatea una velocidad en km/h con su unidad
+func (s System) FormatSpeed(kph float64) string {
+	return fmt.Sprintf("%.1f %s", s.SpeedValue(kph), s.Speed)
+}
+
+// FormatPressure formatea una presión en mb con su unidad
+func (s System) FormatPressure(mb float64) string {
+	if s.Pressure == "inHg" {
+		return fmt.Sprintf("%.2f %s", s.PressureValue(mb), s.Pressure)
+	}
+	return fmt.Sprintf("%.1f %s", s.PressureValue(mb), s.Pressure)
+}
+
+// FormatPrecip formatea una precipitación en mm con su unidad
+func (s System) FormatPrecip(mm float64) string {
+	if s.Precip == "in" {
+		return fmt.Sprintf("%.2f %s", s.PrecipValue(mm), s.Precip)
+	}
+	return fmt.Sprintf("%.1f %s", s.PrecipValue(mm), s.Precip)
+}
+
+// FormatDistance formatea una distancia en km con su unidad
+func (s System) FormatDistance(km float64) string {
+	return fmt.Sprintf("%.1f %s", s.DistanceValue(km), s.Distance)
+}
@@ -0,0 +1,73 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, name := range []string{"metric", "Imperial", " si ", "UK"} {
+		if _, err := Parse(name); err != nil {
+			t.Errorf("Parse(%q): %v", name, err)
+		}
+	}
+	if _, err := Parse("nautical"); err == nil {
+		t.Errorf("Parse(nautical): se esperaba un error")
+	}
+}
+
+func TestConversions(t *testing.T) {
+	imperial, _ := Parse("imperial")
+	si, _ := Parse("si")
+	uk, _ := Parse("uk")
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"0°C en °F", imperial.Temp(0), 32},
+		{"100°C en °F", imperial.Temp(100), 212},
+		{"-40°C en °F", imperial.Temp(-40), -40},
+		{"0°C en K", si.Temp(0), 273.15},
+		{"20°C en métrico", Metric.Temp(20), 20},
+		{"20°C en uk", uk.Temp(20), 20},
+		{"36 km/h en m/s", si.SpeedValue(36), 10},
+		{"100 km/h en mph", imperial.SpeedValue(100), 62.137},
+		{"100 km/h en mph (uk)", uk.SpeedValue(100), 62.137},
+		{"1013.25 mb en inHg", imperial.PressureValue(1013.25), 29.921},
+		{"1013.25 mb en hPa", si.PressureValue(1013.25), 1013.25},
+		{"25.4 mm en in", imperial.PrecipValue(25.4), 1},
+		{"10 km en mi", imperial.DistanceValue(10), 6.214},
+		{"10 km en mi (uk)", uk.DistanceValue(10), 6.214},
+		{"10 km en km", si.DistanceValue(10), 10},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 0.001 {
+			t.Errorf("%s = %v, se esperaba %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	imperial, _ := Parse("imperial")
+	si, _ := Parse("si")
+	tests := []struct {
+		got, want string
+	}{
+		{Metric.FormatTemp(21.46), "21.5°C"},
+		{imperial.FormatTemp(20), "68.0°F"},
+		{si.FormatTemp(0), "273.1 K"},
+		{Metric.FormatSpeed(15), "15.0 km/h"},
+		{si.FormatSpeed(36), "10.0 m/s"},
+		{imperial.FormatPressure(1013.25), "29.92 inHg"},
+		{Metric.FormatPressure(1013.25), "1013.2 mb"},
+		{imperial.FormatPrecip(2.54), "0.10 in"},
+		{Metric.FormatPrecip(2.54), "2.5 mm"},
+		{imperial.FormatDistance(16.09344), "10.0 mi"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%q, se esperaba %q", tt.got, tt.want)
+		}
+	}
+}